@@ -3,39 +3,134 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 
 	"github.com/sunimalherath/orderfoodonline/internal/app/repositories"
 	"github.com/sunimalherath/orderfoodonline/internal/app/services"
+	"github.com/sunimalherath/orderfoodonline/internal/app/tracing"
 	"github.com/sunimalherath/orderfoodonline/internal/config"
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
 	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
 	"github.com/sunimalherath/orderfoodonline/internal/server"
 )
 
 func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if err := entities.RegisterCurrency(cfg.Currency); err != nil {
+		logger.Error(fmt.Sprintf("currency %s: %s", cfg.Currency.Code, err.Error()))
+	} else if err := entities.SetDefaultCurrency(cfg.Currency.Code); err != nil {
+		logger.Error(err.Error())
+	}
 
 	productCache, err := config.LoadProducts()
 	if err != nil {
 		logger.Error(err.Error())
 	}
 
-	productsRepo := repositories.NewProductsRepo(productCache)
+	productsRepo := repositories.NewProductsFileRepo(productCache, constants.ProductsFilePath)
+
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+
+	go watchCatalog(reloadCtx, cfg.Catalog, productsRepo, logger)
+
+	inventoryRepo := repositories.NewInventoryRepo(productsRepo)
+
+	productSvc := services.NewProductService(inventoryRepo)
+
+	metrics := repositories.NewMetrics(inventoryRepo)
+
+	spanExporter, closeExporter, err := newSpanExporter(cfg.Tracing)
+	if err != nil {
+		logger.Error(fmt.Sprintf("traces will not be exported: %s", err.Error()))
+	}
+
+	if closeExporter != nil {
+		defer closeExporter.Close()
+	}
+
+	tracer := tracing.NewTracer(tracing.WithExporter(spanExporter), tracing.WithLogger(logger))
+
+	couponRules, err := config.LoadCouponRules()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	orderSvcOpts := []services.OrderSvcOptions{
+		services.WithLogger(logger),
+		services.WithCouponSources(cfg.Coupons.Sources, cfg.Coupons.Quorum),
+		services.WithDiscountRules(couponRules),
+		services.WithMetrics(metrics),
+		services.WithTracer(tracer),
+	}
 
-	productSvc := services.NewProductService(productsRepo)
-	orderSvc := services.NewOrderSvc(productSvc, services.WithLogger(logger))
+	if couponIndex := loadCouponIndex(cfg.Coupons, logger); couponIndex != nil {
+		defer couponIndex.Close()
 
-	api := server.NewAPIServer(productSvc, orderSvc, server.WithLogger(logger))
+		orderSvcOpts = append(orderSvcOpts, services.WithCouponIndex(couponIndex))
+	}
+
+	// orders accepted into a memory store after the durable one failed would be lost on restart, so do not start.
+	ordersRepo, err := newOrdersRepo(cfg.Orders)
+	if err != nil {
+		logger.Error(fmt.Sprintf("order store %q unavailable: %s", cfg.Orders.Store, err.Error()))
+		os.Exit(1)
+	}
+
+	restoreReservations(ordersRepo, inventoryRepo, logger)
+
+	orderSvc := services.NewOrderSvc(productSvc, ordersRepo, append(orderSvcOpts, services.WithInventory(inventoryRepo))...)
+
+	categoryDetails, err := config.LoadCategoryDetails()
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	apiKeys, err := config.LoadAPIKeys(cfg.Auth)
+	if err != nil {
+		logger.Error(fmt.Sprintf("API keys not loaded, every protected request will be rejected: %s", err.Error()))
+	}
+
+	if !slices.ContainsFunc(apiKeys, func(key entities.APIKey) bool { return key.HasScope(entities.ScopeAdmin) }) {
+		logger.Warn("no API key has the admin scope, admin endpoints will reject every request")
+	}
+
+	api := server.NewAPIServer(productSvc, orderSvc,
+		server.WithLogger(logger),
+		server.WithAPIKeyStore(repositories.NewAPIKeyStore(apiKeys)),
+		server.WithPublicCatalog(cfg.Auth.PublicCatalog),
+		server.WithCORSPolicy(cfg.Server.CORS),
+		server.WithMetrics(metrics),
+		server.WithTracer(tracer),
+		server.WithRateLimiter(repositories.NewRateLimiter(), cfg.Server.RateLimits),
+		server.WithRequestTimeouts(cfg.Server.RequestTimeout, cfg.Server.RouteTimeouts),
+		server.WithIdempotencyStore(repositories.NewIdempotencyStore(cfg.Server.IdempotencyTTL)),
+		server.WithProductAdminService(services.NewProductAdminService(productsRepo, cfg.Catalog.Categories)),
+		server.WithCategoryService(services.NewCategoryService(inventoryRepo, categoryDetails)),
+	)
 
 	httpHandler := api.RegisterRoutes()
 
-	server := createHTTPServer(httpHandler, cfg.Server.Port)
+	// requests derive from baseCtx, so cancelling it aborts the requests still running when shutdown times out
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	server := createHTTPServer(baseCtx, httpHandler, cfg.Server.Port)
 
 	go func() {
 		err = server.ListenAndServe()
@@ -56,14 +151,148 @@ func main() {
 
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Error(err.Error())
+
+		cancelRequests()
+	}
+
+	if closer, ok := ordersRepo.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Error(err.Error())
+		}
 	}
 
 	logger.Info(constants.ShutdownComplete)
 }
 
-func createHTTPServer(h http.Handler, port string) *http.Server {
+// watchCatalog reloads products.json when it changes or on SIGHUP. A catalog that fails to load or validate
+// is logged and the current catalog is kept.
+func watchCatalog(ctx context.Context, cfg config.CatalogConfig, productsRepo adapters.ProductsRepo, logger *slog.Logger) {
+	reload := func() {
+		productCache, err := config.LoadProducts()
+		if err != nil {
+			logger.Error(fmt.Sprintf("product catalog reload rejected, keeping the current catalog: %s", err.Error()))
+
+			return
+		}
+
+		if err := productsRepo.ReplaceProducts(ctx, productCache); err != nil {
+			logger.Error(err.Error())
+
+			return
+		}
+
+		logger.Info("product catalog reloaded", slog.Int("products", len(productCache)))
+	}
+
+	if cfg.ReloadInterval > 0 {
+		go config.WatchFile(ctx, constants.ProductsFilePath, cfg.ReloadInterval, reload)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload()
+		}
+	}
+}
+
+// newSpanExporter: the exporter selected by cfg, nil for none. The closer, if any, is closed on shutdown.
+func newSpanExporter(cfg config.TracingConfig) (adapters.SpanExporter, io.Closer, error) {
+	switch cfg.Exporter {
+	case constants.NoTraceExporter, "":
+		return nil, nil, nil
+	case constants.StdoutTraceExporter:
+		return tracing.NewJSONExporter(os.Stdout), nil, nil
+	case constants.FileTraceExporter:
+		return tracing.NewJSONFileExporter(cfg.FilePath)
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
+
+func newOrdersRepo(cfg config.OrdersConfig) (adapters.OrdersRepo, error) {
+	switch cfg.Store {
+	case constants.FileStore:
+		return repositories.NewOrdersFileRepo(cfg.LogPath)
+	case constants.MemoryStore:
+		return repositories.NewOrdersRepo(), nil
+	default:
+		return nil, fmt.Errorf("unknown order store %q", cfg.Store)
+	}
+}
+
+// restoreReservations reserves the stock of the open orders again, as reservations are only kept in memory.
+func restoreReservations(ordersRepo adapters.OrdersRepo, inventoryRepo adapters.InventoryRepo, logger *slog.Logger) {
+	ctx := context.Background()
+
+	for offset := 0; ; offset += constants.MaxPageLimit {
+		orders, total, err := ordersRepo.ListOrders(ctx, offset, constants.MaxPageLimit)
+		if err != nil {
+			logger.Error(err.Error())
+
+			return
+		}
+
+		for _, order := range orders {
+			if order.Status.IsFinal() {
+				continue
+			}
+
+			if err := inventoryRepo.Reserve(ctx, order.ID, order.Items); err != nil {
+				logger.Warn(fmt.Sprintf("stock of open order %s not reserved: %s", order.ID, err.Error()))
+			}
+		}
+
+		if offset+constants.MaxPageLimit >= total {
+			return
+		}
+	}
+}
+
+// loadCouponIndex opens the configured coupon index, built offline by cmd/couponindex.
+// It returns nil when the index is disabled or unusable, so coupons fall back to file scans.
+func loadCouponIndex(cfg config.CouponConfig, logger *slog.Logger) adapters.CouponIndex {
+	if cfg.IndexPath == "" {
+		return nil
+	}
+
+	couponIndex, err := repositories.OpenCouponIndex(cfg.IndexPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("coupon index unavailable, scanning coupon files instead: %s", err.Error()))
+
+		return nil
+	}
+
+	configured := make([]string, 0, len(cfg.Sources))
+	for _, source := range cfg.Sources {
+		configured = append(configured, source.Name)
+	}
+
+	// source bits in the index are positions in its own source list, so answers from a mismatched index
+	// would name the wrong sources.
+	if !slices.Equal(configured, couponIndex.SourceNames()) {
+		logger.Warn("coupon index was built from different coupon sources, scanning coupon files instead",
+			slog.Any("configured", configured), slog.Any("indexed", couponIndex.SourceNames()))
+
+		_ = couponIndex.Close()
+
+		return nil
+	}
+
+	return couponIndex
+}
+
+func createHTTPServer(baseCtx context.Context, h http.Handler, port string) *http.Server {
 	return &http.Server{
-		Addr:    fmt.Sprintf(":%s", port),
-		Handler: h,
+		Addr:        fmt.Sprintf(":%s", port),
+		Handler:     h,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 }
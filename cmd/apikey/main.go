@@ -0,0 +1,92 @@
+// Command apikey generates an API key and adds its hash to the API keys file. The key itself is printed once
+// and not stored anywhere.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func main() {
+	name := flag.String("name", "", "name of the key, e.g. the client using it")
+	scopes := flag.String("scopes", string(entities.ScopeProductsRead), "comma separated scopes: products:read, orders:read, orders:write, admin")
+	expiresIn := flag.Duration("expires-in", 0, "lifetime of the key, e.g. 720h; 0 never expires")
+	file := flag.String("file", os.Getenv(constants.APIKeysFile), "API keys file to add the key to")
+	flag.Parse()
+
+	if err := run(*name, *scopes, *expiresIn, *file); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(name, scopeList string, expiresIn time.Duration, file string) error {
+	if name == "" || file == "" {
+		return errors.New("-name and -file are required")
+	}
+
+	apiKey := entities.APIKey{Name: name}
+
+	for _, scope := range strings.Split(scopeList, ",") {
+		scope := entities.Scope(strings.TrimSpace(scope))
+		if !slices.Contains(entities.Scopes, scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+
+		apiKey.Scopes = append(apiKey.Scopes, scope)
+	}
+
+	if expiresIn > 0 {
+		expiresAt := time.Now().UTC().Add(expiresIn).Truncate(time.Second)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	keys := []entities.APIKey{}
+
+	keysData, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if len(keysData) > 0 {
+		if err := json.Unmarshal(keysData, &keys); err != nil {
+			return err
+		}
+	}
+
+	if slices.ContainsFunc(keys, func(key entities.APIKey) bool { return key.Name == name }) {
+		return fmt.Errorf("a key named %q already exists in %s", name, file)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	key := hex.EncodeToString(secret)
+	apiKey.Hash = entities.HashAPIKey(key)
+
+	keysData, err = json.MarshalIndent(append(keys, apiKey), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(file, append(keysData, '\n'), 0o600); err != nil {
+		return err
+	}
+
+	fmt.Println(key)
+
+	return nil
+}
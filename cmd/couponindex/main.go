@@ -0,0 +1,43 @@
+// Command couponindex builds the precomputed coupon index offline, so the api does not scan coupon files per order.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/sunimalherath/orderfoodonline/internal/app/repositories"
+	"github.com/sunimalherath/orderfoodonline/internal/config"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	defaultOut := cfg.Coupons.IndexPath
+	if defaultOut == "" {
+		defaultOut = constants.CouponIndexFile
+	}
+
+	out := flag.String("out", defaultOut, "path of the coupon index to write")
+	flag.Parse()
+
+	sources := cfg.Coupons.Sources
+
+	logger.Info("building coupon index", slog.String("out", *out), slog.Any("sources", sources))
+
+	if err := repositories.BuildCouponIndex(context.Background(), sources, *out); err != nil {
+		logger.Error(fmt.Sprintf("coupon index build failed: %s", err.Error()))
+		os.Exit(1)
+	}
+
+	logger.Info("coupon index built", slog.String("out", *out))
+}
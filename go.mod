@@ -6,3 +6,7 @@ require (
 	github.com/google/uuid v1.6.0
 	golang.org/x/sync v0.18.0
 )
+
+require github.com/klauspost/compress v1.18.0
+
+require golang.org/x/text v0.21.0
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type apiKeyStore struct {
+	keys   []entities.APIKey
+	hashes [][]byte
+	now    func() time.Time
+}
+
+// NewAPIKeyStore: keys whose hash is not hex encoded SHA-256 never match.
+func NewAPIKeyStore(keys []entities.APIKey) adapters.APIKeyStore {
+	hashes := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		hashes[i], _ = hex.DecodeString(key.Hash)
+	}
+
+	return &apiKeyStore{
+		keys:   keys,
+		hashes: hashes,
+		now:    time.Now,
+	}
+}
+
+// Authenticate finds the key by its hash. Every stored hash is compared in constant time,
+// so the time taken does not tell which key, or how much of one, matched.
+func (s *apiKeyStore) Authenticate(ctx context.Context, key string) (*entities.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	hash, _ := hex.DecodeString(entities.HashAPIKey(key))
+	match := -1
+
+	for i, stored := range s.hashes {
+		if subtle.ConstantTimeCompare(hash, stored) == 1 {
+			match = i
+		}
+	}
+
+	if match < 0 {
+		return nil, constants.ErrInvalidAPIKey
+	}
+
+	apiKey := s.keys[match]
+
+	if apiKey.Expired(s.now()) {
+		return nil, constants.ErrAPIKeyExpired
+	}
+
+	return &apiKey, nil
+}
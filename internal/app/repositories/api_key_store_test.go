@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func TestAPIKeyStore_Authenticate(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+
+	store := NewAPIKeyStore([]entities.APIKey{
+		{Name: "storefront", Hash: entities.HashAPIKey("storefront-key"), Scopes: []entities.Scope{entities.ScopeProductsRead}},
+		{Name: "old", Hash: entities.HashAPIKey("old-key"), Scopes: []entities.Scope{entities.ScopeAdmin}, ExpiresAt: &expired},
+	})
+
+	tests := []struct {
+		key  string
+		name string
+		err  error
+	}{
+		{key: "storefront-key", name: "storefront"},
+		{key: "storefront-ke", err: constants.ErrInvalidAPIKey},
+		{key: "old-key", err: constants.ErrAPIKeyExpired},
+	}
+
+	for _, tc := range tests {
+		apiKey, err := store.Authenticate(context.Background(), tc.key)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected %v, got %v", tc.key, tc.err, err)
+		}
+
+		if tc.err == nil && apiKey.Name != tc.name {
+			t.Errorf("%s: expected key %s, got %s", tc.key, tc.name, apiKey.Name)
+		}
+	}
+}
@@ -0,0 +1,526 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/sunimalherath/orderfoodonline/internal/app/utils"
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// index file layout (little endian):
+//
+//	magic       [8]byte
+//	sourceCount uint32
+//	sourceNames sourceCount x (uint16 length + bytes)
+//	recordCount uint64
+//	records     recordCount x (code [couponKeyLen]byte zero padded + sources uint32 bitmask)
+//
+// records are sorted by code so a lookup is a binary search over fixed width records. Codes are limited in runes,
+// so a key holds the longest valid code in bytes.
+const (
+	couponIndexMagic  = "CPNIDX02"
+	couponKeyLen      = constants.MaxCouponCodeLen * utf8.UTFMax
+	maxCouponSources  = 32
+	couponRecordWidth = couponKeyLen + 4
+
+	// couponRunRecords: the number of codes sorted in memory at a time while building an index.
+	couponRunRecords = 1 << 20
+)
+
+type couponKey [couponKeyLen]byte
+
+type couponIndex struct {
+	file        *os.File
+	sourceNames []string
+	recordsAt   int64
+	recordCount int64
+}
+
+// OpenCouponIndex opens an index file previously written by BuildCouponIndex.
+func OpenCouponIndex(indexPath string) (adapters.CouponIndex, error) {
+	file, err := os.Open(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &couponIndex{file: file}
+
+	if err := idx.readHeader(); err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// BuildCouponIndex scans every coupon source once and writes a sorted index to indexPath.
+// Codes are sorted in bounded runs written next to indexPath and the runs are merged, so building needs memory
+// for one run rather than for every code. The index is written to a temporary file first and renamed,
+// so readers never see a partial index.
+func BuildCouponIndex(ctx context.Context, sources []entities.CouponSource, indexPath string) error {
+	return buildCouponIndex(ctx, sources, indexPath, couponRunRecords)
+}
+
+func buildCouponIndex(ctx context.Context, sources []entities.CouponSource, indexPath string, runRecords int) error {
+	if len(sources) == 0 || len(sources) > maxCouponSources {
+		return constants.ErrTooManyCouponSources
+	}
+
+	runDir, err := os.MkdirTemp(filepath.Dir(indexPath), filepath.Base(indexPath)+".runs.*")
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(runDir)
+
+	runPaths := []string{}
+
+	for i, source := range sources {
+		paths, err := writeCouponRuns(ctx, source.Path, uint32(1)<<i, runDir, runRecords)
+		if err != nil {
+			return err
+		}
+
+		runPaths = append(runPaths, paths...)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(indexPath), filepath.Base(indexPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if err := writeCouponIndex(ctx, tmpFile, sources, runPaths); err != nil {
+		_ = tmpFile.Close()
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), indexPath)
+}
+
+func (c *couponIndex) Lookup(ctx context.Context, couponCode string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key, ok := toCouponKey(couponCode)
+	if !ok {
+		return nil, nil
+	}
+
+	record := make([]byte, couponRecordWidth)
+
+	low, high := int64(0), c.recordCount-1
+
+	for low <= high {
+		mid := low + (high-low)/2
+
+		if _, err := c.file.ReadAt(record, c.recordsAt+mid*couponRecordWidth); err != nil {
+			return nil, err
+		}
+
+		switch cmp := bytes.Compare(record[:couponKeyLen], key[:]); {
+		case cmp < 0:
+			low = mid + 1
+		case cmp > 0:
+			high = mid - 1
+		default:
+			return c.namesFor(binary.LittleEndian.Uint32(record[couponKeyLen:])), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (c *couponIndex) SourceNames() []string {
+	return c.sourceNames
+}
+
+func (c *couponIndex) Close() error {
+	return c.file.Close()
+}
+
+func (c *couponIndex) namesFor(sources uint32) []string {
+	names := []string{}
+
+	for i, name := range c.sourceNames {
+		if sources&(uint32(1)<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func (c *couponIndex) readHeader() error {
+	reader := bufio.NewReader(c.file)
+
+	magic := make([]byte, len(couponIndexMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != couponIndexMagic {
+		return constants.ErrInvalidCouponIndex
+	}
+
+	offset := int64(len(couponIndexMagic))
+
+	var sourceCount uint32
+	if err := binary.Read(reader, binary.LittleEndian, &sourceCount); err != nil || sourceCount > maxCouponSources {
+		return constants.ErrInvalidCouponIndex
+	}
+
+	offset += 4
+
+	for range sourceCount {
+		var nameLen uint16
+		if err := binary.Read(reader, binary.LittleEndian, &nameLen); err != nil {
+			return constants.ErrInvalidCouponIndex
+		}
+
+		name := make([]byte, nameLen)
+		if _, err := io.ReadFull(reader, name); err != nil {
+			return constants.ErrInvalidCouponIndex
+		}
+
+		c.sourceNames = append(c.sourceNames, string(name))
+		offset += 2 + int64(nameLen)
+	}
+
+	var recordCount uint64
+	if err := binary.Read(reader, binary.LittleEndian, &recordCount); err != nil {
+		return constants.ErrInvalidCouponIndex
+	}
+
+	c.recordsAt = offset + 8
+	c.recordCount = int64(recordCount)
+
+	stat, err := c.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if stat.Size() != c.recordsAt+c.recordCount*couponRecordWidth {
+		return constants.ErrInvalidCouponIndex
+	}
+
+	return nil
+}
+
+// writeCouponRuns splits the codes of the coupon file at sourcePath into sorted runs of at most runRecords
+// codes, writes them to runDir and returns their paths.
+func writeCouponRuns(ctx context.Context, sourcePath string, sourceBit uint32, runDir string, runRecords int,
+) ([]string, error) {
+	file, err := utils.OpenDecompressed(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var (
+		runPaths []string
+		keys     []couponKey
+	)
+
+	scanner := bufio.NewScanner(file)
+
+	for lines := 0; scanner.Scan(); lines++ {
+		if lines%100_000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		key, ok := toCouponKey(entities.NormalizeCouponCode(scanner.Text()))
+		if !ok {
+			continue
+		}
+
+		if keys = append(keys, key); len(keys) < runRecords {
+			continue
+		}
+
+		runPath, err := writeCouponRun(runDir, keys, sourceBit)
+		if err != nil {
+			return nil, err
+		}
+
+		runPaths, keys = append(runPaths, runPath), keys[:0]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(keys) > 0 {
+		runPath, err := writeCouponRun(runDir, keys, sourceBit)
+		if err != nil {
+			return nil, err
+		}
+
+		runPaths = append(runPaths, runPath)
+	}
+
+	return runPaths, nil
+}
+
+// writeCouponRun sorts keys and writes them, without duplicates, as index records of sourceBit to a new run file.
+func writeCouponRun(runDir string, keys []couponKey, sourceBit uint32) (string, error) {
+	slices.SortFunc(keys, compareCouponKeys)
+
+	file, err := os.CreateTemp(runDir, "run.*")
+	if err != nil {
+		return "", err
+	}
+
+	writer := bufio.NewWriter(file)
+	record := make([]byte, couponRecordWidth)
+
+	for _, key := range slices.Compact(keys) {
+		putCouponRecord(record, key, sourceBit)
+
+		if _, err := writer.Write(record); err != nil {
+			_ = file.Close()
+
+			return "", err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+
+		return "", err
+	}
+
+	return file.Name(), file.Close()
+}
+
+// writeCouponIndex writes the index header of sources to file, followed by the records of the sorted runs at
+// runPaths merged into one sorted list. Records of a code found in several runs are combined.
+func writeCouponIndex(ctx context.Context, file *os.File, sources []entities.CouponSource, runPaths []string) error {
+	runs := make(couponRunHeap, 0, len(runPaths))
+
+	defer func() {
+		for _, run := range runs {
+			_ = run.file.Close()
+		}
+	}()
+
+	for _, runPath := range runPaths {
+		run, err := openCouponRun(runPath)
+		if err != nil {
+			return err
+		}
+
+		if run == nil {
+			continue
+		}
+
+		runs = append(runs, run)
+	}
+
+	heap.Init(&runs)
+
+	writer := bufio.NewWriter(file)
+
+	recordCountAt, err := writeCouponIndexHeader(writer, sources)
+	if err != nil {
+		return err
+	}
+
+	var recordCount uint64
+
+	record := make([]byte, couponRecordWidth)
+
+	for runs.Len() > 0 {
+		if recordCount%100_000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		key, sourceBits := runs[0].key, uint32(0)
+
+		for runs.Len() > 0 && runs[0].key == key {
+			sourceBits |= runs[0].sources
+
+			if err := runs.advance(); err != nil {
+				return err
+			}
+		}
+
+		putCouponRecord(record, key, sourceBits)
+
+		if _, err := writer.Write(record); err != nil {
+			return err
+		}
+
+		recordCount++
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	count := make([]byte, 8)
+	binary.LittleEndian.PutUint64(count, recordCount)
+
+	_, err = file.WriteAt(count, recordCountAt)
+
+	return err
+}
+
+// writeCouponIndexHeader writes the index header with a zero record count, and returns the offset of the count
+// so it can be filled in once the records are written.
+func writeCouponIndexHeader(writer *bufio.Writer, sources []entities.CouponSource) (int64, error) {
+	if _, err := writer.WriteString(couponIndexMagic); err != nil {
+		return 0, err
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, uint32(len(sources))); err != nil {
+		return 0, err
+	}
+
+	offset := int64(len(couponIndexMagic)) + 4
+
+	for _, source := range sources {
+		name := source.Name
+
+		if err := binary.Write(writer, binary.LittleEndian, uint16(len(name))); err != nil {
+			return 0, err
+		}
+
+		if _, err := writer.WriteString(name); err != nil {
+			return 0, err
+		}
+
+		offset += 2 + int64(len(name))
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, uint64(0)); err != nil {
+		return 0, err
+	}
+
+	return offset, nil
+}
+
+// couponRun: a sorted run file being merged, positioned at its next record.
+type couponRun struct {
+	file    *os.File
+	reader  *bufio.Reader
+	record  []byte
+	key     couponKey
+	sources uint32
+}
+
+// openCouponRun opens the run at runPath positioned at its first record. It returns nil for an empty run.
+func openCouponRun(runPath string) (*couponRun, error) {
+	file, err := os.Open(runPath)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &couponRun{file: file, reader: bufio.NewReader(file), record: make([]byte, couponRecordWidth)}
+
+	found, err := run.next()
+	if err != nil || !found {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// next reads the next record of the run, it returns false at the end of the run.
+func (r *couponRun) next() (bool, error) {
+	if _, err := io.ReadFull(r.reader, r.record); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	copy(r.key[:], r.record[:couponKeyLen])
+	r.sources = binary.LittleEndian.Uint32(r.record[couponKeyLen:])
+
+	return true, nil
+}
+
+// couponRunHeap: the runs being merged, ordered by their next key.
+type couponRunHeap []*couponRun
+
+func (h couponRunHeap) Len() int           { return len(h) }
+func (h couponRunHeap) Less(i, j int) bool { return compareCouponKeys(h[i].key, h[j].key) < 0 }
+func (h couponRunHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *couponRunHeap) Push(x any) {
+	*h = append(*h, x.(*couponRun))
+}
+
+func (h *couponRunHeap) Pop() any {
+	old := *h
+	run := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return run
+}
+
+// advance moves the run with the smallest key to its next record, closing and dropping it once it is exhausted.
+func (h *couponRunHeap) advance() error {
+	run := (*h)[0]
+
+	found, err := run.next()
+	if err != nil {
+		return err
+	}
+
+	if found {
+		heap.Fix(h, 0)
+
+		return nil
+	}
+
+	heap.Pop(h)
+
+	return run.file.Close()
+}
+
+func putCouponRecord(record []byte, key couponKey, sources uint32) {
+	copy(record, key[:])
+	binary.LittleEndian.PutUint32(record[couponKeyLen:], sources)
+}
+
+func compareCouponKeys(a, b couponKey) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func toCouponKey(couponCode string) (couponKey, bool) {
+	var key couponKey
+
+	if couponCode == "" || utf8.RuneCountInString(couponCode) > constants.MaxCouponCodeLen {
+		return key, false
+	}
+
+	copy(key[:], couponCode)
+
+	return key, true
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func writeCouponFile(t *testing.T, dir, name string, codes ...string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+
+	content := ""
+	for _, code := range codes {
+		content += code + "\n"
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write coupon file: %v", err)
+	}
+
+	return path
+}
+
+func writeGzipCouponFile(t *testing.T, dir, name string, codes ...string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gzWriter := gzip.NewWriter(&buf)
+
+	for _, code := range codes {
+		_, _ = gzWriter.Write([]byte(code + "\n"))
+	}
+
+	if err := gzWriter.Close(); err != nil {
+		t.Fatalf("failed to compress coupon file: %v", err)
+	}
+
+	path := filepath.Join(dir, name)
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("failed to write coupon file: %v", err)
+	}
+
+	return path
+}
+
+func TestCouponIndex_Lookup(t *testing.T) {
+	dir := t.TempDir()
+
+	sources := []entities.CouponSource{
+		{Name: "base1", Path: writeCouponFile(t, dir, "couponbase1", "HAPPYHRS", "FIFTYOFF", "SUPER100", "ÄPFELSAFT9")},
+		{Name: "base2", Path: writeCouponFile(t, dir, "couponbase2", "FIFTYOFF", "HAPPYHRS", "CRLFCODE1\r")},
+		{Name: "base3", Path: writeGzipCouponFile(t, dir, "couponbase3.gz", "HAPPYHRS", "ONLYHERE1")},
+	}
+
+	indexPath := filepath.Join(dir, "coupons.idx")
+
+	if err := BuildCouponIndex(context.Background(), sources, indexPath); err != nil {
+		t.Fatalf("failed to build coupon index: %v", err)
+	}
+
+	assertCouponLookups(t, indexPath)
+}
+
+func TestCouponIndex_LookupMergedRuns(t *testing.T) {
+	dir := t.TempDir()
+
+	sources := []entities.CouponSource{
+		{Name: "base1", Path: writeCouponFile(t, dir, "couponbase1", "SUPER100", "ÄPFELSAFT9", "HAPPYHRS", "FIFTYOFF", "HAPPYHRS")},
+		{Name: "base2", Path: writeCouponFile(t, dir, "couponbase2", "HAPPYHRS", "CRLFCODE1\r", "FIFTYOFF")},
+		{Name: "base3", Path: writeGzipCouponFile(t, dir, "couponbase3.gz", "ONLYHERE1", "HAPPYHRS")},
+	}
+
+	indexPath := filepath.Join(dir, "coupons.idx")
+
+	// runs of two codes force codes of one source to be merged across several runs.
+	if err := buildCouponIndex(context.Background(), sources, indexPath, 2); err != nil {
+		t.Fatalf("failed to build coupon index: %v", err)
+	}
+
+	assertCouponLookups(t, indexPath)
+
+	leftovers, err := filepath.Glob(filepath.Join(dir, "coupons.idx.*"))
+	if err != nil || len(leftovers) > 0 {
+		t.Errorf("expected the runs to be removed, found %v", leftovers)
+	}
+}
+
+func assertCouponLookups(t *testing.T, indexPath string) {
+	t.Helper()
+
+	idx, err := OpenCouponIndex(indexPath)
+	if err != nil {
+		t.Fatalf("failed to open coupon index: %v", err)
+	}
+
+	defer idx.Close()
+
+	tests := []struct {
+		code     string
+		expected []string
+	}{
+		{code: "HAPPYHRS", expected: []string{"base1", "base2", "base3"}},
+		{code: "FIFTYOFF", expected: []string{"base1", "base2"}},
+		{code: "ONLYHERE1", expected: []string{"base3"}},
+		{code: "ÄPFELSAFT9", expected: []string{"base1"}},
+		{code: "CRLFCODE1", expected: []string{"base2"}},
+		{code: "MISSING1", expected: nil},
+		{code: "WAYTOOLONGCODE", expected: nil},
+	}
+
+	for _, tc := range tests {
+		sources, err := idx.Lookup(context.Background(), tc.code)
+		if err != nil {
+			t.Fatalf("lookup %s failed: %v", tc.code, err)
+		}
+
+		if !slices.Equal(sources, tc.expected) {
+			t.Errorf("lookup %s: expected %v, got %v", tc.code, tc.expected, sources)
+		}
+	}
+}
+
+func TestOpenCouponIndex_InvalidFile(t *testing.T) {
+	path := writeCouponFile(t, t.TempDir(), "coupons.idx", "not an index")
+
+	if _, err := OpenCouponIndex(path); err == nil {
+		t.Errorf("expected an error opening an invalid index")
+	}
+}
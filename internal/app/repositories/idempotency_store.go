@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type idempotencyRecord struct {
+	fingerprint string
+	response    *entities.IdempotentResponse
+	expiresAt   time.Time
+}
+
+type idempotencyStore struct {
+	records   map[string]idempotencyRecord
+	ttl       time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+	im        sync.Mutex
+}
+
+// NewIdempotencyStore keeps idempotency keys in memory. Keys expire ttl after they were first used.
+func NewIdempotencyStore(ttl time.Duration) adapters.IdempotencyStore {
+	return &idempotencyStore{
+		records: map[string]idempotencyRecord{},
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// Begin reserves key for the request identified by fingerprint. It returns the recorded response when the same
+// request already completed, ErrIdempotencyKeyInUse while it is still in flight and ErrIdempotencyKeyReused when the
+// key was used for a different request.
+func (i *idempotencyStore) Begin(ctx context.Context, key, fingerprint string) (*entities.IdempotentResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	i.im.Lock()
+	defer i.im.Unlock()
+
+	now := i.now()
+
+	i.sweep(now)
+
+	record, found := i.records[key]
+	if found && now.Before(record.expiresAt) {
+		if record.fingerprint != fingerprint {
+			return nil, constants.ErrIdempotencyKeyReused
+		}
+
+		if record.response == nil {
+			return nil, constants.ErrIdempotencyKeyInUse
+		}
+
+		return record.response, nil
+	}
+
+	i.records[key] = idempotencyRecord{
+		fingerprint: fingerprint,
+		expiresAt:   now.Add(i.ttl),
+	}
+
+	return nil, nil
+}
+
+func (i *idempotencyStore) Complete(ctx context.Context, key string, response entities.IdempotentResponse) error {
+	i.im.Lock()
+	defer i.im.Unlock()
+
+	record, found := i.records[key]
+	if !found {
+		return nil
+	}
+
+	record.response = &response
+	i.records[key] = record
+
+	return nil
+}
+
+// Release forgets key, so the request can be retried with it.
+func (i *idempotencyStore) Release(ctx context.Context, key string) error {
+	i.im.Lock()
+	defer i.im.Unlock()
+
+	delete(i.records, key)
+
+	return nil
+}
+
+// sweep drops expired keys, at most once a minute. The caller holds the lock.
+func (i *idempotencyStore) sweep(now time.Time) {
+	if now.Sub(i.lastSweep) < time.Minute {
+		return
+	}
+
+	for key, record := range i.records {
+		if !now.Before(record.expiresAt) {
+			delete(i.records, key)
+		}
+	}
+
+	i.lastSweep = now
+}
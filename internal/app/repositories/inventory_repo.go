@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// inventoryRepo takes the stock on hand from the catalog and keeps the reservations of open orders in memory.
+// The available stock of a product is its stock on hand less its reserved quantity.
+type inventoryRepo struct {
+	adapters.WritableProductsRepo
+	reserved     map[string]int
+	reservations map[string]map[string]int
+	im           sync.Mutex
+}
+
+// NewInventoryRepo: products without a stock level are not tracked and always available.
+func NewInventoryRepo(prodRepo adapters.WritableProductsRepo) adapters.InventoryRepo {
+	return &inventoryRepo{
+		WritableProductsRepo: prodRepo,
+		reserved:             map[string]int{},
+		reservations:         map[string]map[string]int{},
+	}
+}
+
+func (i *inventoryRepo) GetProducts(ctx context.Context) ([]entities.Product, error) {
+	products, err := i.WritableProductsRepo.GetProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	i.im.Lock()
+	defer i.im.Unlock()
+
+	for idx := range products {
+		i.setAvailability(&products[idx])
+	}
+
+	return products, nil
+}
+
+func (i *inventoryRepo) GetProductByID(ctx context.Context, productID int64) (*entities.Product, error) {
+	product, err := i.WritableProductsRepo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	i.im.Lock()
+	defer i.im.Unlock()
+
+	i.setAvailability(product)
+
+	return product, nil
+}
+
+// Reserve holds the quantities of all items for the order, or none of them when any product is short of stock.
+// Quantities are reserved under the catalog id of the product, so ids naming the same product ("1", "01") share
+// one reservation.
+func (i *inventoryRepo) Reserve(ctx context.Context, orderID string, items []entities.OrderItem) error {
+	i.im.Lock()
+	defer i.im.Unlock()
+
+	if _, found := i.reservations[orderID]; found {
+		return nil
+	}
+
+	quantities := map[string]int{}
+	products := map[string]*entities.Product{}
+
+	for _, item := range items {
+		product, err := i.product(ctx, item.ProductID)
+		if err != nil {
+			return err
+		}
+
+		quantities[product.ID] += item.Quantity
+		products[product.ID] = product
+	}
+
+	for productID, quantity := range quantities {
+		if product := products[productID]; product.Stock != nil && *product.Stock-i.reserved[productID] < quantity {
+			return fmt.Errorf("%w: product %s", constants.ErrInsufficientStock, productID)
+		}
+	}
+
+	for productID, quantity := range quantities {
+		i.reserved[productID] += quantity
+	}
+
+	i.reservations[orderID] = quantities
+
+	return nil
+}
+
+// Release returns the stock reserved for the order, e.g. when it is cancelled.
+func (i *inventoryRepo) Release(ctx context.Context, orderID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	i.im.Lock()
+	defer i.im.Unlock()
+
+	i.unreserve(orderID)
+
+	return nil
+}
+
+// Commit takes the stock reserved for a completed order off the stock on hand in the catalog.
+func (i *inventoryRepo) Commit(ctx context.Context, orderID string) error {
+	i.im.Lock()
+	defer i.im.Unlock()
+
+	for productID, quantity := range i.reservations[orderID] {
+		prodID, err := strconv.ParseInt(productID, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		_, err = i.UpdateProduct(ctx, prodID, func(product *entities.Product) error {
+			if product.Stock != nil {
+				stock := max(0, *product.Stock-quantity)
+				product.Stock = &stock
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, constants.ErrProductNotFound) {
+			return err
+		}
+	}
+
+	i.unreserve(orderID)
+
+	return nil
+}
+
+// unreserve drops the reservation of the order. The caller holds the lock.
+func (i *inventoryRepo) unreserve(orderID string) {
+	for productID, quantity := range i.reservations[orderID] {
+		i.reserved[productID] -= quantity
+
+		if i.reserved[productID] <= 0 {
+			delete(i.reserved, productID)
+		}
+	}
+
+	delete(i.reservations, orderID)
+}
+
+func (i *inventoryRepo) product(ctx context.Context, productID string) (*entities.Product, error) {
+	prodID, err := strconv.ParseInt(productID, 10, 64)
+	if err != nil {
+		return nil, constants.ErrProductNotFound
+	}
+
+	return i.WritableProductsRepo.GetProductByID(ctx, prodID)
+}
+
+// setAvailability replaces the stock on hand of product with its available stock. The caller holds the lock.
+func (i *inventoryRepo) setAvailability(product *entities.Product) {
+	available := true
+
+	if product.Stock != nil {
+		stock := max(0, *product.Stock-i.reserved[product.ID])
+		product.Stock = &stock
+		available = stock > 0
+	}
+
+	product.Available = &available
+}
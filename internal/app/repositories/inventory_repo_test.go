@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func TestInventoryRepo_ReserveEquivalentIDs(t *testing.T) {
+	ctx := context.Background()
+	stock := 2
+
+	prodRepo := NewProductsFileRepo(map[string]entities.Product{
+		"1": {ID: "1", Name: "Waffle with Berries", Stock: &stock},
+	}, filepath.Join(t.TempDir(), "products.json"))
+
+	inventory := NewInventoryRepo(prodRepo)
+
+	if err := inventory.Reserve(ctx, "a", []entities.OrderItem{{ProductID: "1", Quantity: 2}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := inventory.Reserve(ctx, "b", []entities.OrderItem{{ProductID: "01", Quantity: 2}})
+	if !errors.Is(err, constants.ErrInsufficientStock) {
+		t.Fatalf("expected %v reserving the same product under another id, got %v", constants.ErrInsufficientStock, err)
+	}
+
+	err = inventory.Reserve(ctx, "c", []entities.OrderItem{{ProductID: "1", Quantity: 1}, {ProductID: "001", Quantity: 1}})
+	if !errors.Is(err, constants.ErrInsufficientStock) {
+		t.Fatalf("expected %v for one product under two ids, got %v", constants.ErrInsufficientStock, err)
+	}
+
+	if err := inventory.Release(ctx, "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	product, err := inventory.GetProductByID(ctx, 1)
+	if err != nil || *product.Stock != 2 {
+		t.Errorf("expected 2 available after release, got %v (%v)", product, err)
+	}
+}
+
+func TestInventoryRepo_Reserve(t *testing.T) {
+	ctx := context.Background()
+	stock := 5
+
+	prodRepo := NewProductsFileRepo(map[string]entities.Product{
+		"1": {ID: "1", Name: "Waffle with Berries", Stock: &stock},
+		"2": {ID: "2", Name: "Vanilla Bean Crème Brûlée"},
+	}, filepath.Join(t.TempDir(), "products.json"))
+
+	inventory := NewInventoryRepo(prodRepo)
+
+	available := func() int {
+		product, err := inventory.GetProductByID(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return *product.Stock
+	}
+
+	if err := inventory.Reserve(ctx, "a", []entities.OrderItem{{ProductID: "1", Quantity: 3}, {ProductID: "2", Quantity: 100}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := inventory.Reserve(ctx, "b", []entities.OrderItem{{ProductID: "1", Quantity: 2}, {ProductID: "1", Quantity: 1}})
+	if !errors.Is(err, constants.ErrInsufficientStock) {
+		t.Fatalf("expected %v, got %v", constants.ErrInsufficientStock, err)
+	}
+
+	if available() != 2 {
+		t.Errorf("expected 2 available after a rejected reservation, got %d", available())
+	}
+
+	if err := inventory.Release(ctx, "a"); err != nil || available() != 5 {
+		t.Errorf("expected 5 available after release, got %d (%v)", available(), err)
+	}
+
+	if err := inventory.Reserve(ctx, "c", []entities.OrderItem{{ProductID: "1", Quantity: 5}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	product, _ := inventory.GetProductByID(ctx, 1)
+	if *product.Available {
+		t.Errorf("expected product to be unavailable when all stock is reserved")
+	}
+
+	if err := inventory.Commit(ctx, "c"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored, _ := prodRepo.GetProductByID(ctx, 1)
+	if *stored.Stock != 0 || stock != 5 {
+		t.Errorf("expected the catalog stock to drop to 0, got %d", *stored.Stock)
+	}
+}
@@ -0,0 +1,243 @@
+package repositories
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+const metricsNamespace = "orderfoodonline_"
+
+// latencyBuckets: upper bounds in seconds, the defaults of the Prometheus client libraries.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// series: the values of one label set of a counter or histogram.
+type series struct {
+	labelValues []string
+	value       float64
+	buckets     []uint64
+	count       uint64
+}
+
+type metricVec struct {
+	name   string
+	help   string
+	labels []string
+	bounds []float64 // histograms only
+	series map[string]*series
+}
+
+func newMetricVec(name, help string, bounds []float64, labels ...string) *metricVec {
+	return &metricVec{
+		name:   metricsNamespace + name,
+		help:   help,
+		labels: labels,
+		bounds: bounds,
+		series: map[string]*series{},
+	}
+}
+
+func (m *metricVec) with(labelValues ...string) *series {
+	key := strings.Join(labelValues, "\xff")
+
+	s, found := m.series[key]
+	if !found {
+		s = &series{labelValues: labelValues, buckets: make([]uint64, len(m.bounds))}
+		m.series[key] = s
+	}
+
+	return s
+}
+
+func (m *metricVec) inc(labelValues ...string) {
+	m.with(labelValues...).value++
+}
+
+func (m *metricVec) observe(value float64, labelValues ...string) {
+	s := m.with(labelValues...)
+	s.value += value
+	s.count++
+
+	if i, _ := slices.BinarySearch(m.bounds, value); i < len(s.buckets) {
+		s.buckets[i]++
+	}
+}
+
+type prometheusMetrics struct {
+	catalog        adapters.ProductsRepo
+	inFlight       atomic.Int64
+	requests       *metricVec
+	requestLatency *metricVec
+	ordersPlaced   *metricVec
+	couponChecks   *metricVec
+	couponScans    *metricVec
+	mm             sync.Mutex
+}
+
+// NewMetrics keeps the metrics in memory and writes them in the Prometheus text exposition format.
+// The catalog size is read from catalog when the metrics are scraped; a nil catalog leaves it out.
+func NewMetrics(catalog adapters.ProductsRepo) adapters.Metrics {
+	return &prometheusMetrics{
+		catalog: catalog,
+		requests: newMetricVec("http_requests_total", "HTTP requests served.", nil,
+			"method", "route", "status"),
+		requestLatency: newMetricVec("http_request_duration_seconds", "Time taken to serve HTTP requests.", latencyBuckets,
+			"method", "route", "status"),
+		ordersPlaced: newMetricVec("orders_placed_total", "Orders placed.", nil),
+		couponChecks: newMetricVec("coupon_checks_total", "Coupon codes checked, by result.", nil, "result"),
+		couponScans: newMetricVec("coupon_scan_duration_seconds", "Time taken to scan a coupon file.", latencyBuckets,
+			"source"),
+	}
+}
+
+func (p *prometheusMetrics) RequestStarted() {
+	p.inFlight.Add(1)
+}
+
+func (p *prometheusMetrics) RequestFinished(method, route string, status int, duration time.Duration) {
+	p.inFlight.Add(-1)
+
+	p.mm.Lock()
+	defer p.mm.Unlock()
+
+	p.requests.inc(method, route, strconv.Itoa(status))
+	p.requestLatency.observe(duration.Seconds(), method, route, strconv.Itoa(status))
+}
+
+func (p *prometheusMetrics) OrderPlaced() {
+	p.mm.Lock()
+	defer p.mm.Unlock()
+
+	p.ordersPlaced.inc()
+}
+
+func (p *prometheusMetrics) CouponChecked(result entities.CouponCheckResult) {
+	p.mm.Lock()
+	defer p.mm.Unlock()
+
+	p.couponChecks.inc(string(result))
+}
+
+func (p *prometheusMetrics) CouponSourceScanned(source string, duration time.Duration) {
+	p.mm.Lock()
+	defer p.mm.Unlock()
+
+	p.couponScans.observe(duration.Seconds(), source)
+}
+
+// WritePrometheus writes every metric in the text exposition format, series sorted by their label values.
+func (p *prometheusMetrics) WritePrometheus(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	writeGauge(bw, "http_requests_in_flight", "HTTP requests being served.", float64(p.inFlight.Load()))
+
+	if p.catalog != nil {
+		products, err := p.catalog.GetProducts(ctx)
+		if err != nil {
+			return err
+		}
+
+		writeGauge(bw, "catalog_products", "Products in the catalog.", float64(len(products)))
+	}
+
+	p.mm.Lock()
+
+	for _, m := range []*metricVec{p.requests, p.requestLatency, p.ordersPlaced, p.couponChecks, p.couponScans} {
+		m.write(bw)
+	}
+
+	p.mm.Unlock()
+
+	return bw.Flush()
+}
+
+func writeGauge(w io.Writer, name, help string, value float64) {
+	name = metricsNamespace + name
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, formatFloat(value))
+}
+
+func (m *metricVec) write(w io.Writer) {
+	metricType := "counter"
+	if m.bounds != nil {
+		metricType = "histogram"
+	}
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, metricType)
+
+	if len(m.labels) == 0 && len(m.series) == 0 && m.bounds == nil {
+		fmt.Fprintf(w, "%s 0\n", m.name)
+
+		return
+	}
+
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		s := m.series[key]
+		labels := formatLabels(m.labels, s.labelValues)
+
+		if m.bounds == nil {
+			fmt.Fprintf(w, "%s%s %s\n", m.name, labels, formatFloat(s.value))
+
+			continue
+		}
+
+		var cumulative uint64
+
+		for i, bound := range m.bounds {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", m.name, withLabel(labels, "le", formatFloat(bound)), cumulative)
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", m.name, withLabel(labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", m.name, labels, formatFloat(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", m.name, labels, s.count)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names))
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabelValue(values[i])+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+
+	return strings.TrimSuffix(labels, "}") + "," + pair + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func TestMetrics_WritePrometheus(t *testing.T) {
+	catalog := NewProductsRepo(map[string]entities.Product{
+		"1": {ID: "1", Name: "Waffle"},
+		"2": {ID: "2", Name: "Brownie"},
+	})
+
+	metrics := NewMetrics(catalog)
+
+	metrics.RequestStarted()
+	metrics.RequestStarted()
+	metrics.RequestFinished("POST", "POST /order", 200, 30*time.Millisecond)
+	metrics.OrderPlaced()
+	metrics.CouponChecked(entities.CouponValid)
+	metrics.CouponChecked(entities.CouponInvalid)
+	metrics.CouponChecked(entities.CouponInvalid)
+	metrics.CouponSourceScanned(`coupon"base\1`, 2*time.Second)
+
+	var out bytes.Buffer
+	if err := metrics.WritePrometheus(context.Background(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"# TYPE orderfoodonline_http_requests_in_flight gauge\norderfoodonline_http_requests_in_flight 1\n",
+		"orderfoodonline_catalog_products 2\n",
+		"# TYPE orderfoodonline_http_requests_total counter\n" +
+			`orderfoodonline_http_requests_total{method="POST",route="POST /order",status="200"} 1` + "\n",
+		"# TYPE orderfoodonline_http_request_duration_seconds histogram\n",
+		`orderfoodonline_http_request_duration_seconds_bucket{method="POST",route="POST /order",status="200",le="0.025"} 0` + "\n" +
+			`orderfoodonline_http_request_duration_seconds_bucket{method="POST",route="POST /order",status="200",le="0.05"} 1` + "\n",
+		`orderfoodonline_http_request_duration_seconds_bucket{method="POST",route="POST /order",status="200",le="+Inf"} 1` + "\n" +
+			`orderfoodonline_http_request_duration_seconds_sum{method="POST",route="POST /order",status="200"} 0.03` + "\n" +
+			`orderfoodonline_http_request_duration_seconds_count{method="POST",route="POST /order",status="200"} 1` + "\n",
+		"orderfoodonline_orders_placed_total 1\n",
+		`orderfoodonline_coupon_checks_total{result="invalid"} 2` + "\n" + `orderfoodonline_coupon_checks_total{result="valid"} 1` + "\n",
+		`orderfoodonline_coupon_scan_duration_seconds_bucket{source="coupon\"base\\1",le="2.5"} 1` + "\n",
+	}
+
+	for _, want := range expected {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected the output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestMetrics_WritePrometheusBeforeAnyRequest(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewMetrics(nil).WritePrometheus(context.Background(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(out.String(), "catalog_products") {
+		t.Error("expected no catalog size without a catalog")
+	}
+
+	if !strings.Contains(out.String(), "orderfoodonline_orders_placed_total 0\n") {
+		t.Errorf("expected orders placed to start at 0, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// ordersFileRepo keeps orders in memory and appends every saved order to a JSON lines log.
+// The log is replayed on startup, the last record of an order wins.
+type ordersFileRepo struct {
+	*ordersRepo
+	file *os.File
+}
+
+// NewOrdersFileRepo opens, or creates, the order log at logPath and replays it.
+func NewOrdersFileRepo(logPath string) (adapters.OrdersRepo, error) {
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &ordersFileRepo{
+		ordersRepo: newOrdersRepo(),
+		file:       file,
+	}
+
+	if err := repo.replay(); err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+func (o *ordersFileRepo) SaveOrder(ctx context.Context, order entities.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	o.om.Lock()
+	defer o.om.Unlock()
+
+	if err := o.appendRecord(order); err != nil {
+		return err
+	}
+
+	o.put(order)
+
+	return nil
+}
+
+func (o *ordersFileRepo) UpdateOrder(ctx context.Context, orderID string, update func(order *entities.Order) error) (*entities.Order, error) {
+	return o.update(ctx, orderID, update, o.appendRecord)
+}
+
+// appendRecord writes the order to the end of the log. The caller holds the lock.
+func (o *ordersFileRepo) appendRecord(order entities.Order) error {
+	record, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	if _, err := o.file.Write(append(record, '\n')); err != nil {
+		return err
+	}
+
+	return o.file.Sync()
+}
+
+func (o *ordersFileRepo) Close() error {
+	return o.file.Close()
+}
+
+// replay loads the log. A broken last record is the tail of an interrupted write and is truncated,
+// a broken record anywhere else fails the replay.
+func (o *ordersFileRepo) replay() error {
+	reader := bufio.NewReader(o.file)
+
+	var (
+		validEnd     int64
+		offset       int64
+		brokenRecord error
+	)
+
+	for line := 1; ; line++ {
+		record, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		offset += int64(len(record))
+
+		if trimmed := bytes.TrimSpace(record); len(trimmed) > 0 {
+			if brokenRecord != nil {
+				return brokenRecord
+			}
+
+			if order, err := decodeOrderRecord(trimmed); err != nil {
+				brokenRecord = fmt.Errorf("order log line %d: %w", line, err)
+			} else {
+				o.put(order)
+
+				validEnd = offset
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if brokenRecord != nil {
+		if err := o.file.Truncate(validEnd); err != nil {
+			return err
+		}
+	}
+
+	return o.terminateLastRecord()
+}
+
+// orderMoneyFields: the JSON fields of an order, its lines, products and modifiers that hold amounts.
+var orderMoneyFields = map[string]bool{
+	"subtotal": true, "discount": true, "rounding": true, "total": true,
+	"unitPrice": true, "lineTotal": true, "price": true, "priceDelta": true,
+}
+
+// decodeOrderRecord decodes an order from the log. Amounts are logged as decimals in the currency of the order, so
+// they are rebuilt in that currency, not in the default currency, which may have changed since the order was logged.
+func decodeOrderRecord(record []byte) (entities.Order, error) {
+	var order entities.Order
+
+	var header struct {
+		Currency string `json:"currency"`
+	}
+
+	if err := json.Unmarshal(record, &header); err != nil {
+		return order, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(record))
+	decoder.UseNumber()
+
+	var fields any
+	if err := decoder.Decode(&fields); err != nil {
+		return order, err
+	}
+
+	if err := denominateAmounts(fields, header.Currency); err != nil {
+		return order, err
+	}
+
+	denominated, err := json.Marshal(fields)
+	if err != nil {
+		return order, err
+	}
+
+	err = json.Unmarshal(denominated, &order)
+
+	return order, err
+}
+
+// denominateAmounts replaces the decimal amounts in the decoded JSON value with amounts in minor units of currency,
+// in the object form Money unmarshals without the default currency.
+func denominateAmounts(value any, currency string) error {
+	switch value := value.(type) {
+	case map[string]any:
+		for name, field := range value {
+			if decimal, ok := field.(json.Number); ok && orderMoneyFields[name] {
+				amount, err := entities.ParseMoney(decimal.String(), currency)
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+
+				value[name] = map[string]any{"amount": amount.Amount, "currency": amount.Currency}
+
+				continue
+			}
+
+			if err := denominateAmounts(field, currency); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range value {
+			if err := denominateAmounts(item, currency); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// terminateLastRecord makes sure the next record starts on a new line.
+func (o *ordersFileRepo) terminateLastRecord() error {
+	stat, err := o.file.Stat()
+	if err != nil || stat.Size() == 0 {
+		return err
+	}
+
+	lastByte := make([]byte, 1)
+	if _, err := o.file.ReadAt(lastByte, stat.Size()-1); err != nil {
+		return err
+	}
+
+	if lastByte[0] == '\n' {
+		return nil
+	}
+
+	_, err = o.file.Write([]byte{'\n'})
+
+	return err
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func TestOrdersFileRepo_Replay(t *testing.T) {
+	ctx := context.Background()
+	logPath := filepath.Join(t.TempDir(), "orders.log")
+
+	repo, err := NewOrdersFileRepo(logPath)
+	if err != nil {
+		t.Fatalf("failed to open order log: %v", err)
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := repo.SaveOrder(ctx, entities.Order{ID: id}); err != nil {
+			t.Fatalf("failed to save order %s: %v", id, err)
+		}
+	}
+
+	_ = repo.(*ordersFileRepo).Close()
+
+	// simulate a write interrupted half way through
+	file, _ := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0o600)
+	_, _ = file.WriteString(`{"id":"d","ite`)
+	_ = file.Close()
+
+	repo, err = NewOrdersFileRepo(logPath)
+	if err != nil {
+		t.Fatalf("failed to replay order log: %v", err)
+	}
+
+	if err := repo.SaveOrder(ctx, entities.Order{ID: "e"}); err != nil {
+		t.Fatalf("failed to save order: %v", err)
+	}
+
+	orders, total, err := repo.ListOrders(ctx, 0, 2)
+	if err != nil {
+		t.Fatalf("failed to list orders: %v", err)
+	}
+
+	if total != 4 || len(orders) != 2 || orders[0].ID != "e" || orders[1].ID != "c" {
+		t.Errorf("unexpected orders after replay: total %d, %+v", total, orders)
+	}
+
+	if _, err := repo.GetOrderByID(ctx, "a"); err != nil {
+		t.Errorf("expected order a after replay: %v", err)
+	}
+
+	_ = repo.(*ordersFileRepo).Close()
+
+	if _, err := NewOrdersFileRepo(logPath); err != nil {
+		t.Errorf("expected the interrupted write to be truncated: %v", err)
+	}
+}
+
+func TestOrdersFileRepo_ReplayKeepsOrderCurrency(t *testing.T) {
+	ctx := context.Background()
+	logPath := filepath.Join(t.TempDir(), "orders.log")
+
+	defaultCurrency := entities.DefaultCurrency()
+	t.Cleanup(func() { _ = entities.SetDefaultCurrency(defaultCurrency) })
+
+	if err := entities.SetDefaultCurrency("USD"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewOrdersFileRepo(logPath)
+	if err != nil {
+		t.Fatalf("failed to open order log: %v", err)
+	}
+
+	price := entities.NewMoney(655, "USD")
+
+	order := entities.Order{
+		ID:       "a",
+		Products: []entities.Product{{ID: "1", Price: price}},
+		Lines:    []entities.OrderLine{{ProductID: "1", UnitPrice: price, Quantity: 1, LineTotal: price}},
+		Currency: "USD",
+		Subtotal: price,
+		Discount: entities.NewMoney(0, "USD"),
+		Total:    price,
+	}
+
+	if err := repo.SaveOrder(ctx, order); err != nil {
+		t.Fatalf("failed to save order: %v", err)
+	}
+
+	_ = repo.(*ordersFileRepo).Close()
+
+	// the catalog moved to a currency without minor units before the restart.
+	if err := entities.SetDefaultCurrency("JPY"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err = NewOrdersFileRepo(logPath)
+	if err != nil {
+		t.Fatalf("failed to replay order log: %v", err)
+	}
+
+	defer repo.(*ordersFileRepo).Close()
+
+	replayed, err := repo.GetOrderByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("expected order a after replay: %v", err)
+	}
+
+	if replayed.Total != price || replayed.Subtotal != price || replayed.Lines[0].UnitPrice != price ||
+		replayed.Products[0].Price != price || replayed.Discount != entities.NewMoney(0, "USD") {
+		t.Errorf("expected the amounts to stay in USD, got %+v", replayed)
+	}
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type ordersRepo struct {
+	orders   map[string]entities.Order
+	orderIDs []string
+	om       sync.RWMutex
+}
+
+func NewOrdersRepo() adapters.OrdersRepo {
+	return newOrdersRepo()
+}
+
+func newOrdersRepo() *ordersRepo {
+	return &ordersRepo{
+		orders: map[string]entities.Order{},
+	}
+}
+
+func (o *ordersRepo) SaveOrder(ctx context.Context, order entities.Order) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	o.om.Lock()
+	defer o.om.Unlock()
+
+	o.put(order)
+
+	return nil
+}
+
+func (o *ordersRepo) GetOrderByID(ctx context.Context, orderID string) (*entities.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	o.om.RLock()
+
+	order, found := o.orders[orderID]
+
+	o.om.RUnlock()
+
+	if !found {
+		return nil, constants.ErrOrderNotFound
+	}
+
+	return &order, nil
+}
+
+// ListOrders returns a page of orders, most recent first, and the total number of orders.
+func (o *ordersRepo) ListOrders(ctx context.Context, offset, limit int) ([]entities.Order, int, error) {
+	orders := []entities.Order{}
+
+	if err := ctx.Err(); err != nil {
+		return orders, 0, err
+	}
+
+	o.om.RLock()
+	defer o.om.RUnlock()
+
+	total := len(o.orderIDs)
+
+	for i := total - 1 - offset; i >= 0 && len(orders) < limit; i-- {
+		orders = append(orders, o.orders[o.orderIDs[i]])
+	}
+
+	return orders, total, nil
+}
+
+// UpdateOrder applies update to the stored order while holding the lock, so concurrent updates don't interleave.
+// Nothing is stored when update fails.
+func (o *ordersRepo) UpdateOrder(ctx context.Context, orderID string, update func(order *entities.Order) error) (*entities.Order, error) {
+	return o.update(ctx, orderID, update, nil)
+}
+
+// update applies the update under the lock and persists the result with persist, if set, before storing it.
+func (o *ordersRepo) update(
+	ctx context.Context, orderID string, update func(order *entities.Order) error, persist func(order entities.Order) error,
+) (*entities.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	o.om.Lock()
+	defer o.om.Unlock()
+
+	stored, found := o.orders[orderID]
+	if !found {
+		return nil, constants.ErrOrderNotFound
+	}
+
+	order := stored
+	order.StatusHistory = slices.Clone(stored.StatusHistory)
+
+	if err := update(&order); err != nil {
+		return nil, err
+	}
+
+	if persist != nil {
+		if err := persist(order); err != nil {
+			return nil, err
+		}
+	}
+
+	o.put(order)
+
+	return &order, nil
+}
+
+// put stores the order, keeping the position of an order that is saved again. The caller holds the lock.
+func (o *ordersRepo) put(order entities.Order) {
+	if _, found := o.orders[order.ID]; !found {
+		o.orderIDs = append(o.orderIDs, order.ID)
+	}
+
+	o.orders[order.ID] = order
+}
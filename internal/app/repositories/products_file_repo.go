@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// productsFileRepo is a productsRepo that writes the catalog back to its JSON file after every change.
+type productsFileRepo struct {
+	*productsRepo
+	filePath string
+}
+
+func NewProductsFileRepo(prodCache map[string]entities.Product, filePath string) adapters.WritableProductsRepo {
+	if prodCache == nil {
+		prodCache = map[string]entities.Product{}
+	}
+
+	return &productsFileRepo{
+		productsRepo: &productsRepo{prodCache: prodCache},
+		filePath:     filePath,
+	}
+}
+
+// CreateProduct adds the product, assigning the next free numeric ID when the product has none.
+func (p *productsFileRepo) CreateProduct(ctx context.Context, product entities.Product) (*entities.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.cm.Lock()
+	defer p.cm.Unlock()
+
+	if product.ID == "" {
+		product.ID = strconv.FormatInt(p.nextProductID(), 10)
+	}
+
+	if _, found := p.prodCache[product.ID]; found {
+		return nil, constants.ErrProductExists
+	}
+
+	prodCache := maps.Clone(p.prodCache)
+	prodCache[product.ID] = product
+
+	if err := p.commit(prodCache); err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (p *productsFileRepo) UpdateProduct(
+	ctx context.Context, productID int64, update func(product *entities.Product) error,
+) (*entities.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	strProdID := strconv.FormatInt(productID, 10)
+
+	p.cm.Lock()
+	defer p.cm.Unlock()
+
+	product, found := p.prodCache[strProdID]
+	if !found {
+		return nil, constants.ErrProductNotFound
+	}
+
+	if err := update(&product); err != nil {
+		return nil, err
+	}
+
+	product.ID = strProdID
+
+	prodCache := maps.Clone(p.prodCache)
+	prodCache[strProdID] = product
+
+	if err := p.commit(prodCache); err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (p *productsFileRepo) DeleteProduct(ctx context.Context, productID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	strProdID := strconv.FormatInt(productID, 10)
+
+	p.cm.Lock()
+	defer p.cm.Unlock()
+
+	if _, found := p.prodCache[strProdID]; !found {
+		return constants.ErrProductNotFound
+	}
+
+	prodCache := maps.Clone(p.prodCache)
+	delete(prodCache, strProdID)
+
+	return p.commit(prodCache)
+}
+
+// commit writes prodCache to the catalog file and only then makes it the current catalog,
+// so a failed write leaves both unchanged. The caller holds the lock.
+func (p *productsFileRepo) commit(prodCache map[string]entities.Product) error {
+	data, err := json.MarshalIndent(prodCache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(p.filePath), filepath.Base(p.filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if err := tmpFile.Chmod(0o644); err != nil {
+		_ = tmpFile.Close()
+
+		return err
+	}
+
+	if _, err := tmpFile.Write(append(data, '\n')); err != nil {
+		_ = tmpFile.Close()
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpFile.Name(), p.filePath); err != nil {
+		return err
+	}
+
+	p.prodCache = prodCache
+
+	return nil
+}
+
+// nextProductID: one more than the highest numeric product ID. The caller holds the lock.
+func (p *productsFileRepo) nextProductID() int64 {
+	highest := int64(0)
+
+	for id := range p.prodCache {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil && n > highest {
+			highest = n
+		}
+	}
+
+	return highest + 1
+}
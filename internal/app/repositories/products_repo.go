@@ -2,8 +2,11 @@
 package repositories
 
 import (
+	"cmp"
 	"context"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
@@ -37,9 +40,23 @@ func (p *productsRepo) GetProducts(ctx context.Context) ([]entities.Product, err
 
 	p.cm.RUnlock()
 
+	slices.SortFunc(products, compareProductIDs)
+
 	return products, nil
 }
 
+// compareProductIDs orders numeric IDs by value, so "10" comes after "9".
+func compareProductIDs(a, b entities.Product) int {
+	idA, errA := strconv.ParseInt(a.ID, 10, 64)
+	idB, errB := strconv.ParseInt(b.ID, 10, 64)
+
+	if errA != nil || errB != nil {
+		return strings.Compare(a.ID, b.ID)
+	}
+
+	return cmp.Compare(idA, idB)
+}
+
 func (p *productsRepo) GetProductByID(ctx context.Context, productID int64) (*entities.Product, error) {
 	strProdID := strconv.FormatInt(productID, 10)
 
@@ -59,3 +76,18 @@ func (p *productsRepo) GetProductByID(ctx context.Context, productID int64) (*en
 
 	return &prod, nil
 }
+
+// ReplaceProducts swaps the whole catalog at once, readers see either the old or the new catalog.
+func (p *productsRepo) ReplaceProducts(ctx context.Context, prodCache map[string]entities.Product) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.cm.Lock()
+
+	p.prodCache = prodCache
+
+	p.cm.Unlock()
+
+	return nil
+}
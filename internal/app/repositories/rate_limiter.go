@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	fullAt   time.Time
+	capacity int
+}
+
+type rateLimiter struct {
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+	rm        sync.Mutex
+}
+
+// NewRateLimiter keeps a token bucket per key in memory. Buckets that have refilled completely are dropped,
+// as a new bucket behaves the same, so memory is bounded by the clients active within one limit period.
+func NewRateLimiter() adapters.RateLimiter {
+	return &rateLimiter{
+		buckets: map[string]*tokenBucket{},
+		now:     time.Now,
+	}
+}
+
+// Take spends a token of the bucket of key, refilled at limit.Requests tokens per limit.Per.
+func (r *rateLimiter) Take(ctx context.Context, key string, limit entities.RateLimit) (entities.RateLimitDecision, error) {
+	return r.decide(ctx, key, limit, true)
+}
+
+func (r *rateLimiter) Peek(ctx context.Context, key string, limit entities.RateLimit) (entities.RateLimitDecision, error) {
+	return r.decide(ctx, key, limit, false)
+}
+
+// decide refills the bucket of key and decides whether a request is allowed, spending a token when spend is set.
+func (r *rateLimiter) decide(ctx context.Context, key string, limit entities.RateLimit, spend bool,
+) (entities.RateLimitDecision, error) {
+	if err := ctx.Err(); err != nil {
+		return entities.RateLimitDecision{}, err
+	}
+
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return entities.RateLimitDecision{Allowed: true}, nil
+	}
+
+	r.rm.Lock()
+	defer r.rm.Unlock()
+
+	now := r.now()
+
+	r.sweep(now)
+
+	perToken := limit.Per / time.Duration(limit.Requests)
+
+	bucket, found := r.buckets[key]
+	if !found && !spend {
+		return entities.RateLimitDecision{Allowed: true, Limit: limit.Requests, Remaining: limit.Requests}, nil
+	}
+
+	if !found || bucket.capacity != limit.Requests {
+		bucket = &tokenBucket{tokens: float64(limit.Requests), updated: now, capacity: limit.Requests}
+		r.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.updated)
+	bucket.tokens = math.Min(float64(limit.Requests), bucket.tokens+elapsed.Seconds()/perToken.Seconds())
+	bucket.updated = now
+
+	decision := entities.RateLimitDecision{Limit: limit.Requests}
+
+	if bucket.tokens >= 1 {
+		if spend {
+			bucket.tokens--
+		}
+
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = time.Duration((1 - bucket.tokens) * float64(perToken))
+	}
+
+	missing := float64(limit.Requests) - bucket.tokens
+
+	decision.Remaining = int(bucket.tokens)
+	decision.Reset = time.Duration(missing * float64(perToken))
+	bucket.fullAt = now.Add(decision.Reset)
+
+	return decision, nil
+}
+
+// sweep drops the buckets that are full again, at most once a minute. The caller holds the lock.
+func (r *rateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < time.Minute {
+		return
+	}
+
+	for key, bucket := range r.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(r.buckets, key)
+		}
+	}
+
+	r.lastSweep = now
+}
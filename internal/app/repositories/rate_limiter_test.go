@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func TestRateLimiter_Take(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	limiter := NewRateLimiter().(*rateLimiter)
+	limiter.now = func() time.Time { return now }
+
+	limit := entities.RateLimit{Requests: 3, Per: 3 * time.Second}
+
+	for i := range 3 {
+		if decision, _ := limiter.Take(ctx, "kiosk", limit); !decision.Allowed || decision.Remaining != 2-i {
+			t.Fatalf("request %d: expected to be allowed with %d remaining, got %+v", i, 2-i, decision)
+		}
+	}
+
+	if decision, _ := limiter.Peek(ctx, "kiosk", limit); decision.Allowed {
+		t.Errorf("expected peeking at an empty bucket to report a rejection, got %+v", decision)
+	}
+
+	decision, _ := limiter.Take(ctx, "kiosk", limit)
+	if decision.Allowed || decision.RetryAfter != time.Second {
+		t.Errorf("expected a rejection with a retry after 1s, got %+v", decision)
+	}
+
+	if decision, _ := limiter.Peek(ctx, "other", limit); !decision.Allowed || decision.Remaining != 3 {
+		t.Errorf("expected peeking at a new bucket to allow a request, got %+v", decision)
+	}
+
+	if decision, _ := limiter.Take(ctx, "other", limit); !decision.Allowed || decision.Remaining != 2 {
+		t.Errorf("expected other clients to have their own bucket")
+	}
+
+	now = now.Add(time.Second)
+
+	if decision, _ := limiter.Take(ctx, "kiosk", limit); !decision.Allowed {
+		t.Errorf("expected a token to be refilled after 1s, got %+v", decision)
+	}
+
+	now = now.Add(time.Hour)
+
+	_, _ = limiter.Take(ctx, "new", limit)
+
+	if _, found := limiter.buckets["kiosk"]; found {
+		t.Errorf("expected the idle bucket to be dropped")
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type categorySvc struct {
+	prodRepo adapters.ProductsRepo
+	details  map[string]entities.CategoryDetails
+}
+
+// NewCategoryService derives the categories from the product catalog, so they follow catalog reloads and edits.
+// details optionally adds display names, sort orders and images.
+func NewCategoryService(prodRepo adapters.ProductsRepo, details map[string]entities.CategoryDetails) adapters.CategoryService {
+	return &categorySvc{
+		prodRepo: prodRepo,
+		details:  details,
+	}
+}
+
+func (c *categorySvc) ListCategories(ctx context.Context) ([]entities.Category, error) {
+	products, err := c.prodRepo.GetProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return entities.DeriveCategories(products, c.details), nil
+}
+
+func (c *categorySvc) ListCategoryProducts(ctx context.Context, categoryID string) ([]entities.Product, error) {
+	products, err := c.prodRepo.GetProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryProducts := []entities.Product{}
+
+	for _, product := range products {
+		if entities.CategoryID(product.Category) == categoryID {
+			categoryProducts = append(categoryProducts, product)
+		}
+	}
+
+	if len(categoryProducts) == 0 {
+		return nil, constants.ErrCategoryNotFound
+	}
+
+	return categoryProducts, nil
+}
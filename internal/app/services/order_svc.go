@@ -4,22 +4,32 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
-	"os"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 
-	"github.com/sunimalherath/orderfoodonline/internal/config"
+	"github.com/sunimalherath/orderfoodonline/internal/app/tracing"
+	"github.com/sunimalherath/orderfoodonline/internal/app/utils"
 	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
 	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
 	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
 )
 
 type orderSvc struct {
-	productSvc adapters.ProductService
-	logger     *slog.Logger
+	productSvc    adapters.ProductService
+	ordersRepo    adapters.OrdersRepo
+	inventory     adapters.InventoryRepo
+	couponIndex   adapters.CouponIndex
+	couponSources []entities.CouponSource
+	couponQuorum  int
+	discountRules map[string]entities.DiscountRule
+	metrics       adapters.Metrics
+	tracer        adapters.Tracer
+	logger        *slog.Logger
 }
 
 type OrderSvcOptions func(*orderSvc)
@@ -30,9 +40,56 @@ func WithLogger(logger *slog.Logger) OrderSvcOptions {
 	}
 }
 
-func NewOrderSvc(productSvc adapters.ProductService, opts ...OrderSvcOptions) adapters.OrderService {
+// WithCouponIndex: looks coupon codes up in a precomputed index instead of scanning the coupon files.
+func WithCouponIndex(couponIndex adapters.CouponIndex) OrderSvcOptions {
+	return func(o *orderSvc) {
+		o.couponIndex = couponIndex
+	}
+}
+
+// WithCouponSources: a coupon code is valid when at least quorum of the sources contain it.
+func WithCouponSources(sources []entities.CouponSource, quorum int) OrderSvcOptions {
+	return func(o *orderSvc) {
+		o.couponSources = sources
+		o.couponQuorum = quorum
+	}
+}
+
+// WithDiscountRules: the discount applied for a valid coupon code, keyed by code.
+// The rule under constants.AnyCouponCode applies to valid codes without a rule of their own.
+func WithDiscountRules(rules map[string]entities.DiscountRule) OrderSvcOptions {
+	return func(o *orderSvc) {
+		o.discountRules = rules
+	}
+}
+
+// WithInventory: reserves stock for placed orders and releases it when they are cancelled or rejected.
+func WithInventory(inventory adapters.InventoryRepo) OrderSvcOptions {
+	return func(o *orderSvc) {
+		o.inventory = inventory
+	}
+}
+
+// WithMetrics: counts placed orders and coupon checks, and times coupon file scans.
+func WithMetrics(metrics adapters.Metrics) OrderSvcOptions {
+	return func(o *orderSvc) {
+		o.metrics = metrics
+	}
+}
+
+// WithTracer: traces placing orders down to the scan of each coupon file.
+func WithTracer(tracer adapters.Tracer) OrderSvcOptions {
+	return func(o *orderSvc) {
+		o.tracer = tracer
+	}
+}
+
+func NewOrderSvc(productSvc adapters.ProductService, ordersRepo adapters.OrdersRepo, opts ...OrderSvcOptions) adapters.OrderService {
 	odrSvc := &orderSvc{
-		productSvc: productSvc,
+		productSvc:   productSvc,
+		ordersRepo:   ordersRepo,
+		couponQuorum: constants.DefaultCouponQuorum,
+		tracer:       tracing.NewTracer(),
 	}
 
 	for _, opt := range opts {
@@ -43,11 +100,34 @@ func NewOrderSvc(productSvc adapters.ProductService, opts ...OrderSvcOptions) ad
 }
 
 func (o orderSvc) PlaceAnOrder(ctx context.Context, orderReq entities.OrderReq) (*entities.Order, error) {
+	ctx, span := o.tracer.Start(ctx, "orderSvc.PlaceAnOrder")
+	defer span.End()
+
+	span.SetAttribute("order.items", len(orderReq.Items))
+
+	order, err := o.placeAnOrder(ctx, orderReq)
+	if err != nil {
+		span.RecordError(err)
+
+		return nil, err
+	}
+
+	span.SetAttribute("order.id", order.ID)
+
+	return order, nil
+}
+
+func (o orderSvc) placeAnOrder(ctx context.Context, orderReq entities.OrderReq) (*entities.Order, error) {
 	if err := orderReq.Validate(); err != nil {
+		if errors.Is(err, constants.ErrInvalidPromoCodeLength) {
+			o.couponChecked(entities.CouponLengthError)
+		}
+
 		return nil, err
 	}
 
 	products := []entities.Product{}
+	couponApplied := false
 
 	eGroup, errCtx := errgroup.WithContext(ctx)
 
@@ -55,7 +135,8 @@ func (o orderSvc) PlaceAnOrder(ctx context.Context, orderReq entities.OrderReq)
 		err := orderReq.ValidateCouponCode()
 		if err != nil {
 			if !errors.Is(err, constants.ErrEmptyPromoCode) {
-				o.logger.Error(err.Error())
+				o.loggerFor(ctx).Error(err.Error())
+				o.couponChecked(entities.CouponLengthError)
 
 				return err
 			}
@@ -63,13 +144,28 @@ func (o orderSvc) PlaceAnOrder(ctx context.Context, orderReq entities.OrderReq)
 			return nil
 		}
 
-		if validateCouponCode(errCtx, orderReq.CouponCode, config.GetCouponFilePaths()) {
-			o.logger.Info("valid coupon code")
+		valid, err := o.isValidCoupon(errCtx, orderReq.CouponCode)
+		if err != nil {
+			if errCtx.Err() == nil {
+				o.loggerFor(ctx).Error(err.Error())
+				o.couponChecked(entities.CouponCheckError)
+			}
+
+			return err
+		}
+
+		if valid {
+			o.loggerFor(ctx).Info("valid coupon code")
+			o.couponChecked(entities.CouponValid)
+
+			couponApplied = true
 
 			return nil
 		}
 
-		return constants.ErrInvalidPromoCode
+		o.couponChecked(entities.CouponInvalid)
+
+		return entities.FieldError{Field: "couponCode", Err: constants.ErrInvalidPromoCode}
 	})
 
 	eGroup.Go(func() error {
@@ -80,33 +176,168 @@ func (o orderSvc) PlaceAnOrder(ctx context.Context, orderReq entities.OrderReq)
 			return err
 		}
 
-		return nil
+		return orderReq.ValidateModifiers(products)
 	})
 
 	if err := eGroup.Wait(); err != nil {
 		return nil, err
 	}
 
-	return &entities.Order{
+	order := &entities.Order{
 		ID:       uuid.New().String(),
 		Items:    orderReq.Items,
 		Products: products,
+	}
+
+	order.Place(time.Now().UTC())
+
+	var rule *entities.DiscountRule
+
+	if couponApplied {
+		order.CouponCode = orderReq.CouponCode
+		rule = o.discountRuleFor(orderReq.CouponCode)
+	}
+
+	if err := PriceOrder(order, rule); err != nil {
+		return nil, err
+	}
+
+	if o.inventory != nil {
+		if err := o.inventory.Reserve(ctx, order.ID, order.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.ordersRepo.SaveOrder(ctx, *order); err != nil {
+		o.releaseStock(ctx, order.ID)
+
+		return nil, err
+	}
+
+	if o.metrics != nil {
+		o.metrics.OrderPlaced()
+	}
+
+	return order, nil
+}
+
+func (o orderSvc) GetOrderByID(ctx context.Context, orderID string) (*entities.Order, error) {
+	return o.ordersRepo.GetOrderByID(ctx, orderID)
+}
+
+func (o orderSvc) UpdateOrderStatus(ctx context.Context, orderID string, status entities.OrderStatus) (*entities.Order, error) {
+	if !status.IsValid() {
+		return nil, constants.ErrInvalidOrderStatus
+	}
+
+	order, err := o.ordersRepo.UpdateOrder(ctx, orderID, func(order *entities.Order) error {
+		return order.TransitionTo(status, time.Now().UTC())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	o.loggerFor(ctx).Info("order status changed", slog.String("orderId", orderID), slog.String("status", string(status)))
+
+	switch status {
+	case entities.OrderCancelled, entities.OrderRejected:
+		o.releaseStock(ctx, orderID)
+	case entities.OrderCompleted:
+		if o.inventory != nil {
+			if err := o.inventory.Commit(context.WithoutCancel(ctx), orderID); err != nil {
+				o.loggerFor(ctx).Error(fmt.Sprintf("stock of order %s not taken off the catalog: %s", orderID, err.Error()))
+			}
+		}
+	}
+
+	return order, nil
+}
+
+// releaseStock returns the stock reserved for the order. The order is already settled, so a failure is only logged.
+func (o orderSvc) releaseStock(ctx context.Context, orderID string) {
+	if o.inventory == nil {
+		return
+	}
+
+	if err := o.inventory.Release(context.WithoutCancel(ctx), orderID); err != nil {
+		o.loggerFor(ctx).Error(fmt.Sprintf("stock of order %s not released: %s", orderID, err.Error()))
+	}
+}
+
+func (o orderSvc) ListOrders(ctx context.Context, offset, limit int) (*entities.OrderPage, error) {
+	if offset < 0 || limit < 0 {
+		return nil, constants.ErrInvalidPagination
+	}
+
+	if limit == 0 {
+		limit = constants.DefaultPageLimit
+	}
+
+	limit = min(limit, constants.MaxPageLimit)
+
+	orders, total, err := o.ordersRepo.ListOrders(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.OrderPage{
+		Orders: orders,
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
 	}, nil
 }
 
+func (o orderSvc) couponChecked(result entities.CouponCheckResult) {
+	if o.metrics != nil {
+		o.metrics.CouponChecked(result)
+	}
+}
+
+// loggerFor: the logger of the request ctx belongs to, if any.
+func (o orderSvc) loggerFor(ctx context.Context) *slog.Logger {
+	return utils.LoggerFromContext(ctx, o.logger)
+}
+
+func (o orderSvc) discountRuleFor(couponCode string) *entities.DiscountRule {
+	if rule, found := o.discountRules[couponCode]; found {
+		return &rule
+	}
+
+	if rule, found := o.discountRules[constants.AnyCouponCode]; found {
+		return &rule
+	}
+
+	return nil
+}
+
 func (o orderSvc) getProductsForOrder(ctx context.Context, items []entities.OrderItem) ([]entities.Product, error) {
+	ctx, span := o.tracer.Start(ctx, "orderSvc.getProductsForOrder")
+	defer span.End()
+
 	products := []entities.Product{}
 
-	for _, item := range items {
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d].productId", i)
+
 		prodID, err := strconv.Atoi(item.ProductID)
 		if err != nil {
-			o.logger.Error(err.Error())
+			o.loggerFor(ctx).Error(err.Error())
+
+			err = entities.FieldError{Field: field, Err: constants.ErrUnknownOrderProduct}
+			span.RecordError(err)
 
 			return products, err
 		}
 
 		product, err := o.productSvc.FindProductByID(ctx, int64(prodID))
+		if errors.Is(err, constants.ErrProductNotFound) {
+			err = entities.FieldError{Field: field, Err: constants.ErrUnknownOrderProduct}
+		}
+
 		if err != nil {
+			span.RecordError(err)
+
 			return nil, err
 		}
 
@@ -116,42 +347,89 @@ func (o orderSvc) getProductsForOrder(ctx context.Context, items []entities.Orde
 	return products, nil
 }
 
-func validateCouponCode(ctx context.Context, couponCode string, filePaths []string) bool {
+func (o orderSvc) isValidCoupon(ctx context.Context, couponCode string) (bool, error) {
+	var (
+		matched []string
+		err     error
+	)
+
+	if o.couponIndex == nil {
+		matched, err = o.validateCouponCode(ctx, couponCode)
+	} else {
+		matched, err = o.couponIndex.Lookup(ctx, couponCode)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	o.loggerFor(ctx).Info("coupon code sources matched", slog.Any("sources", matched), slog.Int("quorum", o.couponQuorum))
+
+	return len(matched) >= o.couponQuorum, nil
+}
+
+// validateCouponCode scans the coupon sources concurrently and returns the names of the sources
+// containing the code. Scanning stops as soon as quorum sources matched.
+func (o orderSvc) validateCouponCode(ctx context.Context, couponCode string) ([]string, error) {
+	ctx, span := o.tracer.Start(ctx, "orderSvc.validateCouponCode")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	resultCh := make(chan bool, len(filePaths))
+	resultCh := make(chan couponScanResult, len(o.couponSources))
 
-	for _, file := range filePaths {
-		go verifyCouponCode(ctx, couponCode, file, resultCh)
+	for _, source := range o.couponSources {
+		go o.verifyCouponCode(ctx, couponCode, source, resultCh)
 	}
 
-	matches := 0
+	matched := []string{}
 
-	for range filePaths {
+	for range o.couponSources {
 		select {
-		case matchFound := <-resultCh:
-			if matchFound {
-				matches++
+		case result := <-resultCh:
+			if result.found {
+				matched = append(matched, result.source)
 
-				if matches >= 2 {
+				if len(matched) >= o.couponQuorum {
 					cancel()
+					span.SetAttribute("coupon.matched", len(matched))
 
-					return true
+					return matched, nil
 				}
 			}
 		case <-ctx.Done():
-			return false
+			span.RecordError(ctx.Err())
+
+			return matched, ctx.Err()
 		}
 	}
 
-	return false
+	span.SetAttribute("coupon.matched", len(matched))
+
+	return matched, nil
+}
+
+type couponScanResult struct {
+	source string
+	found  bool
 }
 
-func verifyCouponCode(ctx context.Context, couponCode string, filePath string, resultCh chan<- bool) {
-	file, err := os.Open(filePath)
+// verifyCouponCode scans source for couponCode. Scans that were cancelled are not timed, as they did not finish.
+func (o orderSvc) verifyCouponCode(ctx context.Context, couponCode string, source entities.CouponSource,
+	resultCh chan<- couponScanResult,
+) {
+	start := time.Now()
+
+	ctx, span := o.tracer.Start(ctx, "orderSvc.verifyCouponCode")
+	defer span.End()
+
+	span.SetAttribute("coupon.source", source.Name)
+
+	file, err := utils.OpenDecompressed(source.Path)
 	if err != nil {
-		resultCh <- false
+		span.RecordError(err)
+		resultCh <- couponScanResult{source: source.Name}
 
 		return
 	}
@@ -163,16 +441,28 @@ func verifyCouponCode(ctx context.Context, couponCode string, filePath string, r
 	for scanner.Scan() {
 		select {
 		case <-ctx.Done():
+			span.SetAttribute("coupon.cancelled", true)
+
 			return
 		default:
 		}
 
-		if scanner.Text() == couponCode {
-			resultCh <- true
+		if entities.NormalizeCouponCode(scanner.Text()) == couponCode {
+			o.couponSourceScanned(source.Name, start)
+			span.SetAttribute("coupon.found", true)
+			resultCh <- couponScanResult{source: source.Name, found: true}
 
 			return
 		}
 	}
 
-	resultCh <- false
+	o.couponSourceScanned(source.Name, start)
+	span.SetAttribute("coupon.found", false)
+	resultCh <- couponScanResult{source: source.Name}
+}
+
+func (o orderSvc) couponSourceScanned(source string, start time.Time) {
+	if o.metrics != nil {
+		o.metrics.CouponSourceScanned(source, time.Since(start))
+	}
 }
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// PriceOrder fills in the order lines, subtotal, discount, rounding and total from the order items and products.
+// order.Products must be in the same order as order.Items. A nil rule prices the order without a discount.
+func PriceOrder(order *entities.Order, rule *entities.DiscountRule) error {
+	if len(order.Items) != len(order.Products) {
+		return constants.ErrProductNotFound
+	}
+
+	currency := entities.DefaultCurrency()
+	if len(order.Products) > 0 {
+		currency = order.Products[0].Price.Currency
+	}
+
+	lines := make([]entities.OrderLine, 0, len(order.Items))
+	subtotal := entities.NewMoney(0, currency)
+
+	for i, item := range order.Items {
+		product := order.Products[i]
+
+		if product.Price.Currency != currency {
+			return constants.ErrCurrencyMismatch
+		}
+
+		modifiers, err := product.ResolveModifiers(item.Modifiers)
+		if err != nil {
+			return err
+		}
+
+		unitPrice := product.Price
+		for _, modifier := range modifiers {
+			unitPrice = unitPrice.Add(modifier.PriceDelta)
+		}
+
+		line := entities.OrderLine{
+			ProductID: product.ID,
+			Name:      product.Name,
+			Category:  product.Category,
+			Modifiers: modifiers,
+			UnitPrice: unitPrice,
+			Quantity:  item.Quantity,
+			LineTotal: unitPrice.Mul(int64(item.Quantity)),
+		}
+
+		subtotal = subtotal.Add(line.LineTotal)
+		lines = append(lines, line)
+	}
+
+	discount := entities.NewMoney(0, currency)
+
+	if rule != nil {
+		var err error
+
+		discount, err = calculateDiscount(lines, subtotal, *rule)
+		if err != nil {
+			return err
+		}
+	}
+
+	discount = entities.MinMoney(discount, subtotal)
+	total := subtotal.Sub(discount).Round()
+
+	// rounding is what the currency increment adds to or takes off the total, so subtotal - discount + rounding = total.
+	rounding := total.Sub(subtotal.Sub(discount))
+
+	order.Lines = lines
+	order.Currency = currency
+	order.Subtotal = subtotal
+	order.Discount = discount
+	order.Rounding = rounding
+	order.Total = total
+
+	return nil
+}
+
+func calculateDiscount(lines []entities.OrderLine, subtotal entities.Money, rule entities.DiscountRule) (entities.Money, error) {
+	switch rule.Type {
+	case entities.DiscountPercentage:
+		return subtotal.Percent(rule.Percent), nil
+	case entities.DiscountFixedAmount:
+		if !rule.Amount.SameCurrency(subtotal) {
+			return entities.Money{}, constants.ErrCurrencyMismatch
+		}
+
+		return rule.Amount, nil
+	case entities.DiscountFreeCheapest:
+		cheapest := entities.NewMoney(0, subtotal.Currency)
+
+		for i, line := range lines {
+			if i == 0 || line.UnitPrice.Less(cheapest) {
+				cheapest = line.UnitPrice
+			}
+		}
+
+		return cheapest, nil
+	case entities.DiscountCategory:
+		categoryTotal := entities.NewMoney(0, subtotal.Currency)
+
+		for _, line := range lines {
+			if line.Category == rule.Category {
+				categoryTotal = categoryTotal.Add(line.LineTotal)
+			}
+		}
+
+		return categoryTotal.Percent(rule.Percent), nil
+	default:
+		return entities.Money{}, constants.ErrUnknownDiscountRule
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func usd(cents int64) entities.Money {
+	return entities.NewMoney(cents, "USD")
+}
+
+func chf(cents int64) entities.Money {
+	return entities.NewMoney(cents, "CHF")
+}
+
+func newTestOrder() *entities.Order {
+	return newTestOrderIn("USD")
+}
+
+func newTestOrderIn(currency string) *entities.Order {
+	return &entities.Order{
+		Items: []entities.OrderItem{
+			{ProductID: "1", Quantity: 3},
+			{ProductID: "4", Quantity: 2},
+		},
+		Products: []entities.Product{
+			{ID: "1", Name: "Waffle with Berries", Category: "Waffle", Price: entities.NewMoney(650, currency)},
+			{ID: "4", Name: "Classic Tiramisu", Category: "Tiramisu", Price: entities.NewMoney(550, currency)},
+		},
+	}
+}
+
+func TestPriceOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     *entities.DiscountRule
+		discount entities.Money
+		total    entities.Money
+	}{
+		{name: "no coupon", rule: nil, discount: usd(0), total: usd(3050)},
+		{name: "percentage", rule: &entities.DiscountRule{Type: entities.DiscountPercentage, Percent: 10}, discount: usd(305), total: usd(2745)},
+		{name: "fixed amount", rule: &entities.DiscountRule{Type: entities.DiscountFixedAmount, Amount: usd(500)}, discount: usd(500), total: usd(2550)},
+		{name: "fixed amount above subtotal", rule: &entities.DiscountRule{Type: entities.DiscountFixedAmount, Amount: usd(5000)}, discount: usd(3050), total: usd(0)},
+		{name: "free cheapest item", rule: &entities.DiscountRule{Type: entities.DiscountFreeCheapest}, discount: usd(550), total: usd(2500)},
+		{name: "category", rule: &entities.DiscountRule{Type: entities.DiscountCategory, Category: "Waffle", Percent: 50}, discount: usd(975), total: usd(2075)},
+		// 33% of 19.50 is 6.44 and the total of 24.06 is rounded to 24.05.
+		{name: "cash rounded currency", rule: &entities.DiscountRule{Type: entities.DiscountCategory, Category: "Waffle", Percent: 33}, discount: chf(644), total: chf(2405)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			order := newTestOrderIn(tc.total.Currency)
+
+			if err := PriceOrder(order, tc.rule); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if order.Subtotal != entities.NewMoney(3050, tc.total.Currency) {
+				t.Errorf("expected subtotal 30.50, got %v", order.Subtotal)
+			}
+
+			if order.Discount != tc.discount {
+				t.Errorf("expected discount %v, got %v", tc.discount, order.Discount)
+			}
+
+			if order.Total != tc.total {
+				t.Errorf("expected total %v, got %v", tc.total, order.Total)
+			}
+
+			if order.Subtotal.Sub(order.Discount).Add(order.Rounding) != order.Total {
+				t.Errorf("subtotal %v - discount %v + rounding %v is not the total %v", order.Subtotal, order.Discount, order.Rounding, order.Total)
+			}
+
+			if len(order.Lines) != 2 || order.Lines[0].LineTotal.Amount != 1950 || order.Lines[1].LineTotal.Amount != 1100 {
+				t.Errorf("unexpected order lines: %+v", order.Lines)
+			}
+		})
+	}
+}
+
+func TestPriceOrder_Rounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     *entities.DiscountRule
+		discount entities.Money
+		rounding entities.Money
+		total    entities.Money
+	}{
+		// 3 x 6.51 + 2 x 5.50 is 30.53, rounded to 30.55.
+		{name: "no coupon", rule: nil, discount: chf(0), rounding: chf(2), total: chf(3055)},
+		{name: "fixed amount", rule: &entities.DiscountRule{Type: entities.DiscountFixedAmount, Amount: chf(500)}, discount: chf(500), rounding: chf(2), total: chf(2555)},
+		{name: "rounded down", rule: &entities.DiscountRule{Type: entities.DiscountFixedAmount, Amount: chf(501)}, discount: chf(501), rounding: chf(-2), total: chf(2550)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			order := newTestOrderIn("CHF")
+			order.Products[0].Price = chf(651)
+
+			if err := PriceOrder(order, tc.rule); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if order.Subtotal != chf(3053) {
+				t.Errorf("expected subtotal 30.53, got %v", order.Subtotal)
+			}
+
+			if order.Discount != tc.discount || order.Rounding != tc.rounding || order.Total != tc.total {
+				t.Errorf("expected discount %v, rounding %v and total %v, got %v, %v and %v",
+					tc.discount, tc.rounding, tc.total, order.Discount, order.Rounding, order.Total)
+			}
+		})
+	}
+}
+
+func TestPriceOrder_UnknownRule(t *testing.T) {
+	err := PriceOrder(newTestOrder(), &entities.DiscountRule{Type: "bogus"})
+
+	if !errors.Is(err, constants.ErrUnknownDiscountRule) {
+		t.Errorf("expected %v, got %v", constants.ErrUnknownDiscountRule, err)
+	}
+}
+
+func TestPriceOrder_CurrencyMismatch(t *testing.T) {
+	order := newTestOrder()
+	order.Products[1].Price = entities.NewMoney(550, "EUR")
+
+	if err := PriceOrder(order, nil); !errors.Is(err, constants.ErrCurrencyMismatch) {
+		t.Errorf("expected %v, got %v", constants.ErrCurrencyMismatch, err)
+	}
+}
+
+func TestPriceOrder_Modifiers(t *testing.T) {
+	order := newTestOrder()
+	order.Products[0].Modifiers = []entities.ModifierGroup{
+		{ID: "size", Name: "Size", MinSelect: 1, MaxSelect: 1, Options: []entities.ModifierOption{
+			{ID: "s", Name: "Small", PriceDelta: usd(0)},
+			{ID: "l", Name: "Large", PriceDelta: usd(200)},
+		}},
+		{ID: "toppings", Name: "Extra toppings", MinSelect: 0, MaxSelect: 2, Options: []entities.ModifierOption{
+			{ID: "cream", Name: "Whipped cream", PriceDelta: usd(50)},
+			{ID: "syrup", Name: "Maple syrup", PriceDelta: usd(50)},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		modifiers []entities.SelectedModifier
+		lineTotal entities.Money
+		err       error
+	}{
+		{name: "size and toppings", modifiers: []entities.SelectedModifier{
+			{GroupID: "size", OptionID: "l"}, {GroupID: "toppings", OptionID: "cream"}, {GroupID: "toppings", OptionID: "syrup"},
+		}, lineTotal: usd(2850)},
+		{name: "required group missing", modifiers: nil, err: constants.ErrInvalidModifierSelection},
+		{name: "too many options", modifiers: []entities.SelectedModifier{
+			{GroupID: "size", OptionID: "s"}, {GroupID: "size", OptionID: "l"},
+		}, err: constants.ErrInvalidModifierSelection},
+		{name: "option selected twice", modifiers: []entities.SelectedModifier{
+			{GroupID: "size", OptionID: "s"}, {GroupID: "toppings", OptionID: "cream"}, {GroupID: "toppings", OptionID: "cream"},
+		}, err: constants.ErrInvalidModifierSelection},
+		{name: "unknown option", modifiers: []entities.SelectedModifier{{GroupID: "size", OptionID: "xl"}}, err: constants.ErrInvalidModifierSelection},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			order.Items[0].Modifiers = tc.modifiers
+
+			err := PriceOrder(order, nil)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			if tc.err == nil && order.Lines[0].LineTotal != tc.lineTotal {
+				t.Errorf("expected line total %v, got %v", tc.lineTotal, order.Lines[0].LineTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type productAdminSvc struct {
+	prodRepo   adapters.WritableProductsRepo
+	categories []string
+}
+
+// NewProductAdminService: categories are accepted in addition to the categories already in the catalog.
+func NewProductAdminService(prodRepo adapters.WritableProductsRepo, categories []string) adapters.ProductAdminService {
+	return &productAdminSvc{
+		prodRepo:   prodRepo,
+		categories: categories,
+	}
+}
+
+func (p *productAdminSvc) CreateProduct(ctx context.Context, product entities.Product) (*entities.Product, error) {
+	if _, err := strconv.ParseInt(product.ID, 10, 64); product.ID != "" && err != nil {
+		return nil, fmt.Errorf("%w: id must be numeric", constants.ErrInvalidProduct)
+	}
+
+	if err := p.validateProduct(ctx, product); err != nil {
+		return nil, err
+	}
+
+	product.Available = nil
+
+	return p.prodRepo.CreateProduct(ctx, product)
+}
+
+func (p *productAdminSvc) ReplaceProduct(ctx context.Context, productID int64, product entities.Product) (*entities.Product, error) {
+	if err := p.validateProduct(ctx, product); err != nil {
+		return nil, err
+	}
+
+	product.Available = nil
+
+	return p.prodRepo.UpdateProduct(ctx, productID, func(stored *entities.Product) error {
+		*stored = product
+
+		return nil
+	})
+}
+
+func (p *productAdminSvc) PatchProduct(ctx context.Context, productID int64, patch entities.ProductPatch) (*entities.Product, error) {
+	knownCategories, err := p.knownCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.prodRepo.UpdateProduct(ctx, productID, func(stored *entities.Product) error {
+		patch.Apply(stored)
+
+		return validateProductFields(*stored, knownCategories)
+	})
+}
+
+func (p *productAdminSvc) DeleteProduct(ctx context.Context, productID int64) error {
+	return p.prodRepo.DeleteProduct(ctx, productID)
+}
+
+func (p *productAdminSvc) validateProduct(ctx context.Context, product entities.Product) error {
+	knownCategories, err := p.knownCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	return validateProductFields(product, knownCategories)
+}
+
+// knownCategories: the configured categories and the categories of the current catalog.
+func (p *productAdminSvc) knownCategories(ctx context.Context) ([]string, error) {
+	products, err := p.prodRepo.GetProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := slices.Clone(p.categories)
+
+	for _, product := range products {
+		categories = append(categories, product.Category)
+	}
+
+	return categories, nil
+}
+
+func validateProductFields(product entities.Product, knownCategories []string) error {
+	if strings.TrimSpace(product.Name) == "" {
+		return fmt.Errorf("%w: name is required", constants.ErrInvalidProduct)
+	}
+
+	if !product.Price.IsPositive() {
+		return fmt.Errorf("%w: price must be positive", constants.ErrInvalidProduct)
+	}
+
+	if product.Price.Currency != entities.DefaultCurrency() {
+		return fmt.Errorf("%w: price must be in %s", constants.ErrInvalidProduct, entities.DefaultCurrency())
+	}
+
+	if !slices.Contains(knownCategories, product.Category) {
+		return fmt.Errorf("%w: unknown category %q", constants.ErrInvalidProduct, product.Category)
+	}
+
+	return product.ValidateMetadata()
+}
@@ -2,7 +2,10 @@
 package services
 
 import (
+	"cmp"
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
 	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
@@ -19,19 +22,87 @@ func NewProductService(prodRepo adapters.ProductsRepo) adapters.ProductService {
 	}
 }
 
-func (p *productSvc) ListProducts(ctx context.Context) ([]entities.Product, error) {
+// ListProducts returns a page of the products matching the query, an empty page when none match. Products come
+// from the repo in id order, which keeps the order stable for every other sort.
+func (p *productSvc) ListProducts(ctx context.Context, query entities.ProductQuery) (*entities.ProductPage, error) {
+	if query.Offset < 0 || query.Limit < 0 {
+		return nil, constants.ErrInvalidPagination
+	}
+
 	products, err := p.prodRepo.GetProducts(ctx)
 	if err != nil {
-		return products, err
+		return nil, err
 	}
 
-	if len(products) == 0 {
-		return nil, constants.ErrNoProductsAvailable
+	products = slices.DeleteFunc(products, func(product entities.Product) bool {
+		return !matchesProductQuery(product, query)
+	})
+
+	if err := sortProducts(products, query); err != nil {
+		return nil, err
 	}
 
-	return products, nil
+	page := &entities.ProductPage{
+		Total:  len(products),
+		Offset: query.Offset,
+		Limit:  query.Limit,
+	}
+
+	start := min(query.Offset, len(products))
+	end := len(products)
+
+	if query.Limit > 0 {
+		end = min(start+query.Limit, end)
+	}
+
+	page.Products = products[start:end]
+
+	return page, nil
 }
 
 func (p *productSvc) FindProductByID(ctx context.Context, productID int64) (*entities.Product, error) {
 	return p.prodRepo.GetProductByID(ctx, productID)
 }
+
+func matchesProductQuery(product entities.Product, query entities.ProductQuery) bool {
+	if query.Category != "" && !strings.EqualFold(product.Category, query.Category) {
+		return false
+	}
+
+	if query.MinPrice != nil && product.Price.Less(*query.MinPrice) {
+		return false
+	}
+
+	if query.MaxPrice != nil && query.MaxPrice.Less(product.Price) {
+		return false
+	}
+
+	if query.Search != "" && !strings.Contains(strings.ToLower(product.Name), strings.ToLower(query.Search)) {
+		return false
+	}
+
+	return true
+}
+
+// sortProducts sorts products that are in id order by the query sort. Descending reverses the ascending order.
+func sortProducts(products []entities.Product, query entities.ProductQuery) error {
+	switch query.Sort {
+	case "", entities.SortByID:
+	case entities.SortByName:
+		slices.SortStableFunc(products, func(a, b entities.Product) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	case entities.SortByPrice:
+		slices.SortStableFunc(products, func(a, b entities.Product) int {
+			return cmp.Compare(a.Price.Amount, b.Price.Amount)
+		})
+	default:
+		return constants.ErrInvalidProductSort
+	}
+
+	if query.Descending {
+		slices.Reverse(products)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sunimalherath/orderfoodonline/internal/app/repositories"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func newTestProductService() *productSvc {
+	prodCache := map[string]entities.Product{
+		"1":  {ID: "1", Name: "Waffle with Berries", Category: "Waffle", Price: usd(650)},
+		"2":  {ID: "2", Name: "Vanilla Bean Crème Brûlée", Category: "Crème Brûlée", Price: usd(700)},
+		"3":  {ID: "3", Name: "Macaron Mix of Five", Category: "Macaron", Price: usd(800)},
+		"4":  {ID: "4", Name: "Classic Tiramisu", Category: "Tiramisu", Price: usd(550)},
+		"10": {ID: "10", Name: "Berry Waffle Stack", Category: "Waffle", Price: usd(900)},
+	}
+
+	return &productSvc{prodRepo: repositories.NewProductsRepo(prodCache)}
+}
+
+func productIDs(page *entities.ProductPage) []string {
+	ids := []string{}
+
+	for _, product := range page.Products {
+		ids = append(ids, product.ID)
+	}
+
+	return ids
+}
+
+func TestListProducts_Query(t *testing.T) {
+	minPrice, maxPrice := usd(600), usd(850)
+
+	tests := []struct {
+		name     string
+		query    entities.ProductQuery
+		expected []string
+		total    int
+	}{
+		{name: "stable id order", query: entities.ProductQuery{}, expected: []string{"1", "2", "3", "4", "10"}, total: 5},
+		{name: "category", query: entities.ProductQuery{Category: "waffle"}, expected: []string{"1", "10"}, total: 2},
+		{name: "price range", query: entities.ProductQuery{MinPrice: &minPrice, MaxPrice: &maxPrice}, expected: []string{"1", "2", "3"}, total: 3},
+		{name: "name search", query: entities.ProductQuery{Search: "berr"}, expected: []string{"1", "10"}, total: 2},
+		{name: "price descending", query: entities.ProductQuery{Sort: entities.SortByPrice, Descending: true}, expected: []string{"10", "3", "2", "1", "4"}, total: 5},
+		{name: "name", query: entities.ProductQuery{Sort: entities.SortByName}, expected: []string{"10", "4", "3", "2", "1"}, total: 5},
+		{name: "page", query: entities.ProductQuery{Offset: 1, Limit: 2}, expected: []string{"2", "3"}, total: 5},
+		{name: "offset past the end", query: entities.ProductQuery{Offset: 9, Limit: 2}, expected: []string{}, total: 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := newTestProductService().ListProducts(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ids := productIDs(page); !slices.Equal(ids, tc.expected) || page.Total != tc.total {
+				t.Errorf("expected %v of %d, got %v of %d", tc.expected, tc.total, ids, page.Total)
+			}
+		})
+	}
+}
+
+func TestListProducts_EmptyCatalog(t *testing.T) {
+	svc := &productSvc{prodRepo: repositories.NewProductsRepo(map[string]entities.Product{})}
+
+	page, err := svc.ListProducts(context.Background(), entities.ProductQuery{Category: "waffle"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if page.Products == nil || len(page.Products) != 0 || page.Total != 0 {
+		t.Errorf("expected an empty page, got %+v", page)
+	}
+}
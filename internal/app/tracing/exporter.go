@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type jsonExporter struct {
+	w  io.Writer
+	jm sync.Mutex
+}
+
+// NewJSONExporter writes every span as a line of JSON to w, e.g. os.Stdout.
+func NewJSONExporter(w io.Writer) adapters.SpanExporter {
+	return &jsonExporter{w: w}
+}
+
+func (j *jsonExporter) ExportSpan(span entities.SpanRecord) error {
+	line, err := json.Marshal(span)
+	if err != nil {
+		return err
+	}
+
+	j.jm.Lock()
+	defer j.jm.Unlock()
+
+	_, err = j.w.Write(append(line, '\n'))
+
+	return err
+}
+
+// NewJSONFileExporter appends every span as a line of JSON to the file at path. Close the file when done.
+func NewJSONFileExporter(path string) (adapters.SpanExporter, io.Closer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewJSONExporter(file), file, nil
+}
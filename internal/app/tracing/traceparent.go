@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	traceParentVersion = "00"
+	sampledFlag        = 0x01
+)
+
+// parseTraceParent parses a W3C traceparent header: version-traceid-parentid-flags, all lowercase hex.
+// Versions after 00 may append fields, which are ignored. All zero ids and version ff are invalid.
+func parseTraceParent(header string) (spanContext, bool) {
+	var (
+		sc             spanContext
+		version, flags [1]byte
+	)
+
+	header = strings.TrimSpace(header)
+	if len(header) < 55 || strings.ToLower(header) != header {
+		return sc, false
+	}
+
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' || (len(header) > 55 && header[55] != '-') {
+		return sc, false
+	}
+
+	if _, err := hex.Decode(version[:], []byte(header[:2])); err != nil || version[0] == 0xff {
+		return sc, false
+	}
+
+	if header[:2] == traceParentVersion && len(header) != 55 {
+		return sc, false
+	}
+
+	if _, err := hex.Decode(sc.traceID[:], []byte(header[3:35])); err != nil || sc.traceID == [16]byte{} {
+		return sc, false
+	}
+
+	if _, err := hex.Decode(sc.spanID[:], []byte(header[36:52])); err != nil || sc.spanID == [8]byte{} {
+		return sc, false
+	}
+
+	if _, err := hex.Decode(flags[:], []byte(header[53:55])); err != nil {
+		return sc, false
+	}
+
+	sc.sampled = flags[0]&sampledFlag != 0
+
+	return sc, true
+}
+
+func formatTraceParent(sc spanContext) string {
+	flags := "00"
+	if sc.sampled {
+		flags = "01"
+	}
+
+	return traceParentVersion + "-" + hex.EncodeToString(sc.traceID[:]) + "-" + hex.EncodeToString(sc.spanID[:]) + "-" + flags
+}
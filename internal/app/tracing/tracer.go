@@ -0,0 +1,174 @@
+// Package tracing: spans with W3C trace context propagation, exported through a pluggable adapters.SpanExporter.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+type spanContextKey struct{}
+
+type tracer struct {
+	exporter adapters.SpanExporter
+	logger   *slog.Logger
+}
+
+type TracerOptions func(*tracer)
+
+// WithExporter: where finished spans of sampled traces go. Without an exporter spans are only propagated.
+func WithExporter(exporter adapters.SpanExporter) TracerOptions {
+	return func(t *tracer) {
+		t.exporter = exporter
+	}
+}
+
+// WithLogger: logs spans that could not be exported.
+func WithLogger(logger *slog.Logger) TracerOptions {
+	return func(t *tracer) {
+		t.logger = logger
+	}
+}
+
+// NewTracer samples every trace that does not come with a remote parent, and follows the sampling decision of
+// remote parents.
+func NewTracer(opts ...TracerOptions) adapters.Tracer {
+	t := &tracer{}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, adapters.Span) {
+	s := &span{
+		tracer:     t,
+		name:       name,
+		start:      time.Now(),
+		attributes: map[string]any{},
+	}
+
+	if parent, found := ctx.Value(spanContextKey{}).(spanContext); found {
+		s.sc = spanContext{traceID: parent.traceID, sampled: parent.sampled}
+		s.parentID = hex.EncodeToString(parent.spanID[:])
+	} else {
+		s.sc = spanContext{sampled: true}
+		_, _ = rand.Read(s.sc.traceID[:])
+	}
+
+	_, _ = rand.Read(s.sc.spanID[:])
+
+	return context.WithValue(ctx, spanContextKey{}, s.sc), s
+}
+
+func (t *tracer) Extract(ctx context.Context, traceparent string) context.Context {
+	sc, ok := parseTraceParent(traceparent)
+	if !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+func (t *tracer) export(record entities.SpanRecord) {
+	if t.exporter == nil {
+		return
+	}
+
+	if err := t.exporter.ExportSpan(record); err != nil && t.logger != nil {
+		t.logger.Error(err.Error(), slog.String("span", record.Name), slog.String("traceId", record.TraceID))
+	}
+}
+
+type span struct {
+	tracer     *tracer
+	sc         spanContext
+	parentID   string
+	name       string
+	start      time.Time
+	attributes map[string]any
+	err        error
+	ended      bool
+	sm         sync.Mutex
+}
+
+func (s *span) SetAttribute(key string, value any) {
+	s.sm.Lock()
+	defer s.sm.Unlock()
+
+	s.attributes[key] = value
+}
+
+func (s *span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.sm.Lock()
+	defer s.sm.Unlock()
+
+	s.err = err
+}
+
+// End exports the span once, if its trace is sampled.
+func (s *span) End() {
+	end := time.Now()
+
+	s.sm.Lock()
+
+	if s.ended {
+		s.sm.Unlock()
+
+		return
+	}
+
+	s.ended = true
+
+	record := entities.SpanRecord{
+		TraceID:      s.TraceID(),
+		SpanID:       hex.EncodeToString(s.sc.spanID[:]),
+		ParentSpanID: s.parentID,
+		Name:         s.name,
+		Start:        s.start,
+		End:          end,
+		DurationMs:   float64(end.Sub(s.start).Microseconds()) / 1000,
+		Status:       entities.SpanOK,
+	}
+
+	if len(s.attributes) > 0 {
+		record.Attributes = s.attributes
+	}
+
+	if s.err != nil {
+		record.Status = entities.SpanError
+		record.Error = s.err.Error()
+	}
+
+	s.sm.Unlock()
+
+	if s.sc.sampled {
+		s.tracer.export(record)
+	}
+}
+
+func (s *span) TraceID() string {
+	return hex.EncodeToString(s.sc.traceID[:])
+}
+
+func (s *span) TraceParent() string {
+	return formatTraceParent(s.sc)
+}
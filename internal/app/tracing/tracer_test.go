@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		valid   bool
+		sampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with more fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"version 00 with more fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero parent id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", false, false},
+		{"not hex", "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := parseTraceParent(tt.header)
+			if ok != tt.valid {
+				t.Fatalf("expected valid %v, got %v", tt.valid, ok)
+			}
+
+			if ok && sc.sampled != tt.sampled {
+				t.Errorf("expected sampled %v, got %v", tt.sampled, sc.sampled)
+			}
+
+			if ok && tt.header[:2] == traceParentVersion && formatTraceParent(sc) != tt.header {
+				t.Errorf("expected %s to format back to itself, got %s", tt.header, formatTraceParent(sc))
+			}
+		})
+	}
+}
+
+func TestTracer_Propagation(t *testing.T) {
+	var out bytes.Buffer
+
+	tracer := NewTracer(WithExporter(NewJSONExporter(&out)))
+
+	ctx := tracer.Extract(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx, parent := tracer.Start(ctx, "POST /order")
+	_, child := tracer.Start(ctx, "orderSvc.PlaceAnOrder")
+
+	child.SetAttribute("order.items", 2)
+	child.RecordError(errors.New("insufficient stock"))
+	child.End()
+	child.End()
+	parent.End()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 exported spans, got %d:\n%s", len(lines), out.String())
+	}
+
+	var childRecord, parentRecord entities.SpanRecord
+
+	if err := json.Unmarshal([]byte(lines[0]), &childRecord); err != nil {
+		t.Fatalf("failed to decode span: %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &parentRecord); err != nil {
+		t.Fatalf("failed to decode span: %v", err)
+	}
+
+	if parentRecord.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || parentRecord.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("expected the remote parent to be continued, got %+v", parentRecord)
+	}
+
+	if childRecord.TraceID != parentRecord.TraceID || childRecord.ParentSpanID != parentRecord.SpanID {
+		t.Errorf("expected a child of %s, got %+v", parentRecord.SpanID, childRecord)
+	}
+
+	if childRecord.Status != entities.SpanError || childRecord.Error != "insufficient stock" ||
+		childRecord.Attributes["order.items"] != float64(2) {
+		t.Errorf("unexpected child span %+v", childRecord)
+	}
+
+	if parent.TraceParent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-"+parentRecord.SpanID+"-01" {
+		t.Errorf("unexpected traceparent %s", parent.TraceParent())
+	}
+}
+
+func TestTracer_NotSampled(t *testing.T) {
+	var out bytes.Buffer
+
+	tracer := NewTracer(WithExporter(NewJSONExporter(&out)))
+
+	ctx := tracer.Extract(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	_, span := tracer.Start(ctx, "GET /product")
+	span.End()
+
+	if out.Len() != 0 {
+		t.Errorf("expected no span exported for a trace that is not sampled, got %s", out.String())
+	}
+
+	if !strings.HasSuffix(span.TraceParent(), "-00") {
+		t.Errorf("expected the sampling decision to propagate, got %s", span.TraceParent())
+	}
+}
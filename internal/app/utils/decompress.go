@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+type decompressedFile struct {
+	io.Reader
+	closers []func() error
+}
+
+func (d *decompressedFile) Close() error {
+	var firstErr error
+
+	for _, closeFn := range d.closers {
+		if err := closeFn(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// OpenDecompressed opens a plain, gzip or zstd compressed file, detected by its magic bytes,
+// and returns a reader over the decompressed content.
+func OpenDecompressed(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(file)
+
+	header, err := reader.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		gzReader, err := gzip.NewReader(reader)
+		if err != nil {
+			_ = file.Close()
+
+			return nil, err
+		}
+
+		return &decompressedFile{Reader: gzReader, closers: []func() error{gzReader.Close, file.Close}}, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		zstdReader, err := zstd.NewReader(reader)
+		if err != nil {
+			_ = file.Close()
+
+			return nil, err
+		}
+
+		closeZstd := func() error {
+			zstdReader.Close()
+
+			return nil
+		}
+
+		return &decompressedFile{Reader: zstdReader, closers: []func() error{closeZstd, file.Close}}, nil
+	default:
+		return &decompressedFile{Reader: reader, closers: []func() error{file.Close}}, nil
+	}
+}
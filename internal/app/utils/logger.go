@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying logger, e.g. a logger with the id of the request being served.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFromContext: the logger ctx carries, or fallback when it carries none.
+func LoggerFromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+
+	return fallback
+}
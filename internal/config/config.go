@@ -3,9 +3,19 @@ package config
 
 import (
 	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/sunimalherath/orderfoodonline/internal/app/utils"
 	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
@@ -13,21 +23,167 @@ import (
 )
 
 type Config struct {
-	Server ServerConfig
+	Server   ServerConfig
+	Catalog  CatalogConfig
+	Coupons  CouponConfig
+	Currency entities.Currency
+	Orders   OrdersConfig
+	Auth     AuthConfig
+	Tracing  TracingConfig
 }
 
+// ServerConfig: RequestTimeout is the deadline of a request, RouteTimeouts overrides it per route pattern,
+// e.g. "POST /order". RateLimits are the request limits per client by route pattern, "*" for the other routes.
 type ServerConfig struct {
-	Port string
+	Port           string
+	IdempotencyTTL time.Duration
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+	RateLimits     map[string]entities.RateLimit
+	CORS           entities.CORSPolicy
 }
 
-func Load() *Config {
+// CatalogConfig: ReloadInterval is how often products.json is checked for changes, 0 disables the polling.
+// The catalog is also reloaded on SIGHUP. Categories are accepted for new products on top of the catalog's own.
+type CatalogConfig struct {
+	ReloadInterval time.Duration
+	Categories     []string
+}
+
+// CouponConfig: a coupon code is valid when at least Quorum of the Sources contain it.
+// IndexPath is the precomputed coupon index, an empty path disables the index.
+type CouponConfig struct {
+	Sources   []entities.CouponSource
+	Quorum    int
+	IndexPath string
+}
+
+// OrdersConfig: Store is "memory" or "file". The file store appends orders to LogPath and replays it on startup.
+type OrdersConfig struct {
+	Store   string
+	LogPath string
+}
+
+// AuthConfig: KeysFile is a JSON file of hashed API keys. APIKey and AdminKey are single plain keys from the
+// environment, kept for deployments without a keys file.
+// PublicCatalog makes the product and category endpoints callable without an API key.
+type AuthConfig struct {
+	KeysFile      string
+	APIKey        string
+	AdminKey      string
+	PublicCatalog bool
+}
+
+// TracingConfig: Exporter is where spans go, "none", "stdout" or "file". The file exporter appends them to FilePath.
+type TracingConfig struct {
+	Exporter string
+	FilePath string
+}
+
+// Load reads the configuration from the environment, after the env file. Settings that are unsafe or contradict
+// each other are returned as an error, wrapping constants.ErrInvalidConfig.
+func Load() (*Config, error) {
 	loadEnvFile(constants.EnvFilePath)
 
+	couponSources, err := parseCouponSources(utils.GetEnvVar(constants.CouponSources, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	couponQuorum, err := parseCouponQuorum(utils.GetEnvVar(constants.CouponQuorum, ""), len(couponSources))
+	if err != nil {
+		return nil, err
+	}
+
+	corsPolicy, err := loadCORSPolicy()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		ServerConfig{
-			Port: utils.GetEnvVar(constants.PORT, "8080"),
+		Server: ServerConfig{
+			Port:           utils.GetEnvVar(constants.PORT, "8080"),
+			IdempotencyTTL: parseDuration(utils.GetEnvVar(constants.IdempotencyTTL, ""), constants.DefaultIdempotencyTTL),
+			RequestTimeout: parseDuration(utils.GetEnvVar(constants.RequestTimeout, ""), constants.DefaultRequestTimeout),
+			RouteTimeouts:  parseRouteTimeouts(utils.GetEnvVar(constants.RouteTimeouts, "")),
+			RateLimits:     parseRateLimits(utils.GetEnvVar(constants.RateLimits, constants.DefaultRateLimits)),
+			CORS:           corsPolicy,
+		},
+		Coupons: CouponConfig{
+			Sources:   couponSources,
+			Quorum:    couponQuorum,
+			IndexPath: utils.GetEnvVar(constants.CouponIndexPath, ""),
+		},
+		Catalog: CatalogConfig{
+			ReloadInterval: parseDuration(utils.GetEnvVar(constants.ProductsReloadInterval, ""), constants.DefaultReloadInterval),
+			Categories:     parseList(utils.GetEnvVar(constants.ProductCategories, "")),
+		},
+		Currency: loadCurrency(),
+		Orders: OrdersConfig{
+			Store:   utils.GetEnvVar(constants.OrdersStore, constants.MemoryStore),
+			LogPath: utils.GetEnvVar(constants.OrdersLogPath, constants.OrdersLogFile),
+		},
+		Auth: AuthConfig{
+			KeysFile:      utils.GetEnvVar(constants.APIKeysFile, ""),
+			APIKey:        utils.GetEnvVar(constants.APIKey, ""),
+			AdminKey:      utils.GetEnvVar(constants.AdminAPIKey, ""),
+			PublicCatalog: utils.GetEnvVar(constants.PublicCatalog, "true") == "true",
 		},
+		Tracing: TracingConfig{
+			Exporter: utils.GetEnvVar(constants.TraceExporter, constants.NoTraceExporter),
+			FilePath: utils.GetEnvVar(constants.TraceFilePath, constants.TracesFile),
+		},
+	}, nil
+}
+
+// loadCORSPolicy: with credentials allowed, every allowed origin must be exact, as any other origin matched
+// would be echoed back to browsers that then send it their credentials.
+func loadCORSPolicy() (entities.CORSPolicy, error) {
+	policy := entities.CORSPolicy{
+		AllowedOrigins:   parseList(utils.GetEnvVar(constants.CORSAllowedOrigins, "*")),
+		AllowedMethods:   parseList(utils.GetEnvVar(constants.CORSAllowedMethods, constants.DefaultCORSMethods)),
+		AllowedHeaders:   parseList(utils.GetEnvVar(constants.CORSAllowedHeaders, constants.DefaultCORSHeaders)),
+		ExposedHeaders:   parseList(utils.GetEnvVar(constants.CORSExposedHeaders, constants.DefaultCORSExposedHeaders)),
+		AllowCredentials: utils.GetEnvVar(constants.CORSAllowCredentials, "false") == "true",
+		MaxAge:           parseDuration(utils.GetEnvVar(constants.CORSMaxAge, ""), constants.DefaultCORSMaxAge),
 	}
+
+	if !policy.AllowCredentials {
+		return policy, nil
+	}
+
+	for _, origin := range policy.AllowedOrigins {
+		if strings.Contains(origin, "*") {
+			return policy, fmt.Errorf("%w: %s=true needs exact %s, not %q", constants.ErrInvalidConfig,
+				constants.CORSAllowCredentials, constants.CORSAllowedOrigins, origin)
+		}
+	}
+
+	return policy, nil
+}
+
+// loadCurrency: the currency of the catalog, with its rounding rules optionally overridden by the environment.
+func loadCurrency() entities.Currency {
+	code := strings.ToUpper(utils.GetEnvVar(constants.CurrencyCode, "USD"))
+
+	currency, found := entities.LookupCurrency(code)
+	if !found {
+		currency = entities.Currency{Code: code, MinorUnits: 2, Rounding: entities.RoundHalfUp, Increment: 1}
+	}
+
+	if minorUnits, err := strconv.Atoi(utils.GetEnvVar(constants.CurrencyMinorUnits, "")); err == nil {
+		currency.MinorUnits = minorUnits
+	}
+
+	if rounding := utils.GetEnvVar(constants.CurrencyRounding, ""); rounding != "" {
+		currency.Rounding = entities.RoundingMode(strings.ToLower(rounding))
+	}
+
+	if increment, err := strconv.ParseInt(utils.GetEnvVar(constants.CurrencyIncrement, ""), 10, 64); err == nil {
+		currency.Increment = increment
+	}
+
+	return currency
 }
 
 func LoadProducts() (map[string]entities.Product, error) {
@@ -42,17 +198,374 @@ func LoadProducts() (map[string]entities.Product, error) {
 		return nil, err
 	}
 
+	if err := ValidateProducts(prodCache); err != nil {
+		return nil, err
+	}
+
 	return prodCache, nil
 }
 
-func GetCouponFilePaths() []string {
-	return []string{
-		constants.CouponFilePath1,
-		constants.CouponFilePath2,
-		constants.CouponFilePath3,
+// LoadCategoryDetails loads the optional display details of the product categories, keyed by category name.
+func LoadCategoryDetails() (map[string]entities.CategoryDetails, error) {
+	detailsData, err := os.ReadFile(constants.CategoriesPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]entities.CategoryDetails{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var details map[string]entities.CategoryDetails
+
+	if err := json.Unmarshal(detailsData, &details); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// LoadAPIKeys loads the hashed keys of cfg.KeysFile and adds the keys set in the environment: api_key with the
+// products:read, orders:read and orders:write scopes and admin_api_key with the admin scope. Without a keys file and
+// api_key, the default development key is used.
+func LoadAPIKeys(cfg AuthConfig) ([]entities.APIKey, error) {
+	keys := []entities.APIKey{}
+
+	if cfg.KeysFile != "" {
+		keysData, err := os.ReadFile(cfg.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(keysData, &keys); err != nil {
+			return nil, fmt.Errorf("%w: %w", constants.ErrInvalidAPIKeys, err)
+		}
+	}
+
+	apiKey := cfg.APIKey
+	if apiKey == "" && cfg.KeysFile == "" {
+		apiKey = constants.DefaultAPIKey
+	}
+
+	if apiKey != "" {
+		keys = append(keys, entities.APIKey{
+			Name:   constants.APIKey,
+			Hash:   entities.HashAPIKey(apiKey),
+			Scopes: []entities.Scope{entities.ScopeProductsRead, entities.ScopeOrdersRead, entities.ScopeOrdersWrite},
+		})
+	}
+
+	if cfg.AdminKey != "" {
+		keys = append(keys, entities.APIKey{
+			Name:   constants.AdminAPIKey,
+			Hash:   entities.HashAPIKey(cfg.AdminKey),
+			Scopes: []entities.Scope{entities.ScopeAdmin},
+		})
+	}
+
+	if err := validateAPIKeys(keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// validateAPIKeys checks every key has a unique name, a SHA-256 hash and known scopes.
+func validateAPIKeys(keys []entities.APIKey) error {
+	names := map[string]bool{}
+
+	for _, key := range keys {
+		if key.Name == "" || names[key.Name] {
+			return fmt.Errorf("%w: key names must be unique and not empty", constants.ErrInvalidAPIKeys)
+		}
+
+		names[key.Name] = true
+
+		if hash, err := hex.DecodeString(key.Hash); err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("%w: key %s: hash must be a hex encoded SHA-256", constants.ErrInvalidAPIKeys, key.Name)
+		}
+
+		if len(key.Scopes) == 0 {
+			return fmt.Errorf("%w: key %s has no scopes", constants.ErrInvalidAPIKeys, key.Name)
+		}
+
+		for _, scope := range key.Scopes {
+			if !slices.Contains(entities.Scopes, scope) {
+				return fmt.Errorf("%w: key %s: unknown scope %q", constants.ErrInvalidAPIKeys, key.Name, scope)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateProducts checks every product is stored under its numeric ID, has a name, a positive price and valid
+// metadata, and that the whole catalog is priced in one currency.
+func ValidateProducts(prodCache map[string]entities.Product) error {
+	if len(prodCache) == 0 {
+		return constants.ErrNoProductsAvailable
+	}
+
+	currency := ""
+
+	for key, product := range prodCache {
+		if product.ID != key {
+			return fmt.Errorf("product %s: %w: stored under key %s", product.ID, constants.ErrInvalidProduct, key)
+		}
+
+		if _, err := strconv.ParseInt(product.ID, 10, 64); err != nil {
+			return fmt.Errorf("product %s: %w: id must be numeric", product.ID, constants.ErrInvalidProduct)
+		}
+
+		if strings.TrimSpace(product.Name) == "" {
+			return fmt.Errorf("product %s: %w: name is required", product.ID, constants.ErrInvalidProduct)
+		}
+
+		if !product.Price.IsPositive() {
+			return fmt.Errorf("product %s: %w: price must be positive", product.ID, constants.ErrInvalidProduct)
+		}
+
+		if err := product.ValidateMetadata(); err != nil {
+			return fmt.Errorf("product %s: %w", product.ID, err)
+		}
+
+		if currency != "" && product.Price.Currency != currency {
+			return fmt.Errorf("product %s: %w", product.ID, constants.ErrCurrencyMismatch)
+		}
+
+		currency = product.Price.Currency
+	}
+
+	return nil
+}
+
+// WatchFile calls onChange whenever the modification time or size of path changes, checking every interval,
+// until ctx is done.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	lastStat, _ := os.Stat(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stat, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if lastStat == nil || !stat.ModTime().Equal(lastStat.ModTime()) || stat.Size() != lastStat.Size() {
+			lastStat = stat
+
+			onChange()
+		}
 	}
 }
 
+// parseCouponSources parses a comma separated list of name=path pairs, e.g.
+// "couponbase1=./data/couponbase1,couponbase2=./data/couponbase2.gz".
+// A bare path is named after its file. An empty list falls back to the bundled coupon files.
+// Names must be unique, as quorum counts the matching sources by name and the coupon index stores them as bits.
+func parseCouponSources(value string) ([]entities.CouponSource, error) {
+	sources := []entities.CouponSource{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, path, found := strings.Cut(entry, "=")
+		if !found {
+			path = name
+			name = filepath.Base(name)
+		}
+
+		source := entities.CouponSource{
+			Name: strings.TrimSpace(name),
+			Path: resolveCompressedPath(strings.TrimSpace(path)),
+		}
+
+		if slices.ContainsFunc(sources, func(other entities.CouponSource) bool { return other.Name == source.Name }) {
+			return nil, fmt.Errorf("%w: %s names coupon source %q more than once", constants.ErrInvalidConfig,
+				constants.CouponSources, source.Name)
+		}
+
+		sources = append(sources, source)
+	}
+
+	if len(sources) > 0 {
+		return sources, nil
+	}
+
+	return []entities.CouponSource{
+		{Name: constants.CouponBase1, Path: resolveCompressedPath(constants.CouponFilePath1)},
+		{Name: constants.CouponBase2, Path: resolveCompressedPath(constants.CouponFilePath2)},
+		{Name: constants.CouponBase3, Path: resolveCompressedPath(constants.CouponFilePath3)},
+	}, nil
+}
+
+// LoadCouponRules loads the discount rules per coupon code from the coupon rules file and validates them.
+// A missing rules file means no discounts, an invalid rule fails the whole file.
+func LoadCouponRules() (map[string]entities.DiscountRule, error) {
+	rulesData, err := os.ReadFile(constants.CouponRulesPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]entities.DiscountRule{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rules map[string]entities.DiscountRule
+
+	if err := json.Unmarshal(rulesData, &rules); err != nil {
+		return nil, err
+	}
+
+	for code, rule := range rules {
+		if err := validateDiscountRule(rule); err != nil {
+			return nil, fmt.Errorf("coupon %s: %w", code, err)
+		}
+	}
+
+	return rules, nil
+}
+
+// validateDiscountRule checks that rule has the fields its type needs, within range.
+func validateDiscountRule(rule entities.DiscountRule) error {
+	switch rule.Type {
+	case entities.DiscountPercentage:
+		if rule.Percent <= 0 || rule.Percent > 100 {
+			return constants.ErrInvalidDiscountRule
+		}
+	case entities.DiscountFixedAmount:
+		if !rule.Amount.IsPositive() {
+			return constants.ErrInvalidDiscountRule
+		}
+	case entities.DiscountFreeCheapest:
+	case entities.DiscountCategory:
+		if rule.Category == "" || rule.Percent <= 0 || rule.Percent > 100 {
+			return constants.ErrInvalidDiscountRule
+		}
+	default:
+		return constants.ErrUnknownDiscountRule
+	}
+
+	return nil
+}
+
+// parseCouponQuorum accepts a match count from 1 to sourceCount, "any" or "all". Without a value the default quorum
+// applies, kept within the number of sources.
+func parseCouponQuorum(value string, sourceCount int) (int, error) {
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "":
+		return max(1, min(constants.DefaultCouponQuorum, sourceCount)), nil
+	case "any":
+		return 1, nil
+	case "all":
+		return sourceCount, nil
+	}
+
+	quorum, err := strconv.Atoi(value)
+	if err != nil || quorum < 1 || quorum > sourceCount {
+		return 0, fmt.Errorf("%w: %s must be any, all or a count from 1 to %d, not %q", constants.ErrInvalidConfig,
+			constants.CouponQuorum, sourceCount, value)
+	}
+
+	return quorum, nil
+}
+
+// resolveCompressedPath returns the first of path, path.gz and path.zst that exists,
+// so the coupon files can be shipped compressed.
+func resolveCompressedPath(path string) string {
+	for _, ext := range constants.CompressedFileExts {
+		if _, err := os.Stat(path + ext); err == nil {
+			return path + ext
+		}
+	}
+
+	return path
+}
+
+// parseList splits a comma separated list, dropping empty entries.
+func parseList(value string) []string {
+	list := []string{}
+
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
+
+// parseRouteTimeouts parses a comma separated list of pattern=duration pairs, e.g. "POST /order=10s,GET /product=2s".
+// Entries without a valid positive duration are skipped.
+func parseRouteTimeouts(value string) map[string]time.Duration {
+	timeouts := map[string]time.Duration{}
+
+	for _, entry := range parseList(value) {
+		pattern, duration, found := strings.Cut(entry, "=")
+		if !found {
+			continue
+		}
+
+		if timeout := parseDuration(strings.TrimSpace(duration), 0); timeout > 0 {
+			timeouts[strings.Join(strings.Fields(pattern), " ")] = timeout
+		}
+	}
+
+	return timeouts
+}
+
+// parseRateLimits parses a comma separated list of pattern=requests/period pairs, e.g. "POST /order=30/1m,*=300/1m".
+// "off" disables rate limiting. Entries that don't parse are skipped.
+func parseRateLimits(value string) map[string]entities.RateLimit {
+	limits := map[string]entities.RateLimit{}
+
+	if strings.EqualFold(strings.TrimSpace(value), "off") {
+		return limits
+	}
+
+	for _, entry := range parseList(value) {
+		pattern, limit, _ := strings.Cut(entry, "=")
+
+		requests, period, _ := strings.Cut(limit, "/")
+		period = strings.TrimSpace(period)
+
+		if period != "" && !unicode.IsDigit(rune(period[0])) {
+			period = "1" + period
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(requests))
+		if err != nil || count <= 0 {
+			continue
+		}
+
+		if per := parseDuration(period, 0); per > 0 {
+			limits[strings.Join(strings.Fields(pattern), " ")] = entities.RateLimit{Requests: count, Per: per}
+		}
+	}
+
+	return limits
+}
+
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return fallback
+	}
+
+	return duration
+}
+
 func loadEnvFile(filename string) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -0,0 +1,11 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type APIKeyStore interface {
+	Authenticate(ctx context.Context, key string) (*entities.APIKey, error)
+}
@@ -5,7 +5,17 @@ import "net/http"
 type APIServer interface {
 	RegisterRoutes() http.Handler
 	HealthCheck(w http.ResponseWriter, r *http.Request)
+	Metrics(w http.ResponseWriter, r *http.Request)
 	ListProducts(w http.ResponseWriter, r *http.Request)
 	FindProductByID(w http.ResponseWriter, r *http.Request)
+	ListCategories(w http.ResponseWriter, r *http.Request)
+	ListCategoryProducts(w http.ResponseWriter, r *http.Request)
 	PlaceAnOrder(w http.ResponseWriter, r *http.Request)
+	GetOrderByID(w http.ResponseWriter, r *http.Request)
+	ListOrders(w http.ResponseWriter, r *http.Request)
+	UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
+	CreateProduct(w http.ResponseWriter, r *http.Request)
+	ReplaceProduct(w http.ResponseWriter, r *http.Request)
+	PatchProduct(w http.ResponseWriter, r *http.Request)
+	DeleteProduct(w http.ResponseWriter, r *http.Request)
 }
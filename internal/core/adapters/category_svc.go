@@ -0,0 +1,12 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type CategoryService interface {
+	ListCategories(ctx context.Context) ([]entities.Category, error)
+	ListCategoryProducts(ctx context.Context, categoryID string) ([]entities.Product, error)
+}
@@ -0,0 +1,9 @@
+package adapters
+
+import "context"
+
+type CouponIndex interface {
+	Lookup(ctx context.Context, couponCode string) ([]string, error)
+	SourceNames() []string
+	Close() error
+}
@@ -0,0 +1,13 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type IdempotencyStore interface {
+	Begin(ctx context.Context, key, fingerprint string) (*entities.IdempotentResponse, error)
+	Complete(ctx context.Context, key string, response entities.IdempotentResponse) error
+	Release(ctx context.Context, key string) error
+}
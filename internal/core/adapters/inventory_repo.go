@@ -0,0 +1,15 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// InventoryRepo: a ProductsRepo whose products carry their available stock, and the stock reserved by open orders.
+type InventoryRepo interface {
+	ProductsRepo
+	Reserve(ctx context.Context, orderID string, items []entities.OrderItem) error
+	Release(ctx context.Context, orderID string) error
+	Commit(ctx context.Context, orderID string) error
+}
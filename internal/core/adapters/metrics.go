@@ -0,0 +1,19 @@
+package adapters
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// Metrics: records what the api serves and exposes it for scraping.
+type Metrics interface {
+	RequestStarted()
+	RequestFinished(method, route string, status int, duration time.Duration)
+	OrderPlaced()
+	CouponChecked(result entities.CouponCheckResult)
+	CouponSourceScanned(source string, duration time.Duration)
+	WritePrometheus(ctx context.Context, w io.Writer) error
+}
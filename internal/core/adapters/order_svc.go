@@ -8,4 +8,7 @@ import (
 
 type OrderService interface {
 	PlaceAnOrder(ctx context.Context, orderReq entities.OrderReq) (*entities.Order, error)
+	GetOrderByID(ctx context.Context, orderID string) (*entities.Order, error)
+	ListOrders(ctx context.Context, offset, limit int) (*entities.OrderPage, error)
+	UpdateOrderStatus(ctx context.Context, orderID string, status entities.OrderStatus) (*entities.Order, error)
 }
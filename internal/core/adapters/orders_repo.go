@@ -0,0 +1,14 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type OrdersRepo interface {
+	SaveOrder(ctx context.Context, order entities.Order) error
+	GetOrderByID(ctx context.Context, orderID string) (*entities.Order, error)
+	ListOrders(ctx context.Context, offset, limit int) ([]entities.Order, int, error)
+	UpdateOrder(ctx context.Context, orderID string, update func(order *entities.Order) error) (*entities.Order, error)
+}
@@ -0,0 +1,14 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type ProductAdminService interface {
+	CreateProduct(ctx context.Context, product entities.Product) (*entities.Product, error)
+	ReplaceProduct(ctx context.Context, productID int64, product entities.Product) (*entities.Product, error)
+	PatchProduct(ctx context.Context, productID int64, patch entities.ProductPatch) (*entities.Product, error)
+	DeleteProduct(ctx context.Context, productID int64) error
+}
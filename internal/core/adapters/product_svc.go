@@ -7,6 +7,6 @@ import (
 )
 
 type ProductService interface {
-	ListProducts(ctx context.Context) ([]entities.Product, error)
+	ListProducts(ctx context.Context, query entities.ProductQuery) (*entities.ProductPage, error)
 	FindProductByID(ctx context.Context, productID int64) (*entities.Product, error)
 }
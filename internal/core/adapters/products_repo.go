@@ -10,4 +10,13 @@ import (
 type ProductsRepo interface {
 	GetProducts(ctx context.Context) ([]entities.Product, error)
 	GetProductByID(ctx context.Context, productID int64) (*entities.Product, error)
+	ReplaceProducts(ctx context.Context, prodCache map[string]entities.Product) error
+}
+
+// WritableProductsRepo: a ProductsRepo whose changes are persisted.
+type WritableProductsRepo interface {
+	ProductsRepo
+	CreateProduct(ctx context.Context, product entities.Product) (*entities.Product, error)
+	UpdateProduct(ctx context.Context, productID int64, update func(product *entities.Product) error) (*entities.Product, error)
+	DeleteProduct(ctx context.Context, productID int64) error
 }
@@ -0,0 +1,13 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+type RateLimiter interface {
+	Take(ctx context.Context, key string, limit entities.RateLimit) (entities.RateLimitDecision, error)
+	// Peek reports the decision Take would make, without spending a token.
+	Peek(ctx context.Context, key string, limit entities.RateLimit) (entities.RateLimitDecision, error)
+}
@@ -0,0 +1,27 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// Tracer starts spans. A span started from a context that carries a span, or a remote parent, is its child.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Extract returns ctx with the remote parent of a W3C traceparent header, or ctx unchanged when it is invalid.
+	Extract(ctx context.Context, traceparent string) context.Context
+}
+
+// Span: one timed operation of a trace. End exports it; the other methods must be called before End.
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+	TraceID() string
+	TraceParent() string
+}
+
+type SpanExporter interface {
+	ExportSpan(span entities.SpanRecord) error
+}
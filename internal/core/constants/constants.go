@@ -7,35 +7,126 @@ import (
 
 // env vairable names
 const (
-	PORT   = "PORT"
-	APIKey = "api_key"
+	PORT        = "PORT"
+	APIKey      = "api_key"
+	AdminAPIKey = "admin_api_key"
+	APIKeysFile = "API_KEYS_FILE"
+
+	PublicCatalog = "PUBLIC_CATALOG"
+
+	CouponIndexPath = "COUPON_INDEX_PATH"
+	CouponSources   = "COUPON_SOURCES"
+	CouponQuorum    = "COUPON_QUORUM"
+
+	CurrencyCode       = "CURRENCY"
+	CurrencyMinorUnits = "CURRENCY_MINOR_UNITS"
+	CurrencyRounding   = "CURRENCY_ROUNDING"
+	CurrencyIncrement  = "CURRENCY_ROUNDING_INCREMENT"
+
+	OrdersStore   = "ORDERS_STORE"
+	OrdersLogPath = "ORDERS_LOG_PATH"
+
+	IdempotencyTTL = "IDEMPOTENCY_TTL"
+
+	RequestTimeout = "REQUEST_TIMEOUT"
+	RouteTimeouts  = "ROUTE_TIMEOUTS"
+	RateLimits     = "RATE_LIMITS"
+
+	CORSAllowedOrigins   = "CORS_ALLOWED_ORIGINS"
+	CORSAllowedMethods   = "CORS_ALLOWED_METHODS"
+	CORSAllowedHeaders   = "CORS_ALLOWED_HEADERS"
+	CORSExposedHeaders   = "CORS_EXPOSED_HEADERS"
+	CORSAllowCredentials = "CORS_ALLOW_CREDENTIALS"
+	CORSMaxAge           = "CORS_MAX_AGE"
+
+	TraceExporter = "TRACE_EXPORTER"
+	TraceFilePath = "TRACE_FILE"
+
+	ProductsReloadInterval = "PRODUCTS_RELOAD_INTERVAL"
+	ProductCategories      = "PRODUCT_CATEGORIES"
 )
 
 // http response types for writing JSON response.
 const (
 	SUCCESS = "success"
-	FAILURE = "faiure"
+	FAILURE = "failure"
 )
 
 // messages to show in the http response.
 const (
-	RetrievalFailed  = "could not retrieve products"
 	ProductsRcvd     = "products retrieved"
-	InvalidProdID    = "invalid product ID"
-	ProdNotFound     = "product not found"
-	InvalidRequest   = "invalid order detail"
-	ValidationFailed = "order validation failed"
-	OrderFailed      = "failed place the order"
 	OrderPlaced      = "order placed"
+	OrderRcvd        = "order retrieved"
+	OrdersRcvd       = "orders retrieved"
+	StatusUpdated    = "order status updated"
+	ProductCreated   = "product created"
+	ProductUpdated   = "product updated"
+	ProductDeleted   = "product deleted"
+	CategoriesRcvd   = "categories retrieved"
+	RequestTimedOut  = "request timed out"
+	RequestCancelled = "request cancelled by the client"
+	TooManyRequests  = "too many requests"
 	GoodHealth       = "health ok"
 )
 
 const CheckHealth = "performing health check"
 
+// coupon validation, coupon code lengths are in runes.
+const (
+	DefaultCouponQuorum = 2
+	MinCouponCodeLen    = 8
+	MaxCouponCodeLen    = 10
+)
+
+// order request limits.
+const (
+	MaxOrderItems   = 50
+	MaxItemQuantity = 100
+)
+
+// pagination.
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// order stores.
+const (
+	MemoryStore = "memory"
+	FileStore   = "file"
+)
+
+// trace exporters.
+const (
+	NoTraceExporter     = "none"
+	StdoutTraceExporter = "stdout"
+	FileTraceExporter   = "file"
+)
+
+// AnyCouponCode: key of the discount rule applied to valid coupon codes without a rule of their own.
+const AnyCouponCode = "*"
+
+// DefaultRateLimits: per client, POST /order 30 requests a minute, other routes 300 a minute.
+const DefaultRateLimits = "POST /order=30/1m,*=300/1m"
+
+// default CORS policy, any origin may call the api without credentials.
+const (
+	DefaultCORSMethods        = "GET, POST, PUT, PATCH, DELETE"
+	DefaultCORSHeaders        = "Accept, Content-Type, api_key, admin_key, Idempotency-Key, X-Request-ID, traceparent"
+	DefaultCORSExposedHeaders = "X-Request-ID, traceresponse, Idempotent-Replayed, Retry-After, RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset"
+)
+
+// AnyRoute: key of the rate limit of routes without a limit of their own.
+const AnyRoute = "*"
+
+// DefaultAPIKey: the development api key used when no key is configured.
+const DefaultAPIKey = "apitest"
+
 // auth messages
 const (
 	MissingAPIkey = "missing API key"
 	InvalidAPIkey = "invalid API key"
+	MissingScope  = "API key does not have the required scope"
 )
 
 // graceful shtudown messages
@@ -46,8 +137,11 @@ const (
 
 // time specific.
 const (
-	ActiveDuration  time.Duration = 30 * time.Second
-	ShutdownTimeout time.Duration = 10 * time.Second
+	DefaultRequestTimeout time.Duration = 30 * time.Second
+	ShutdownTimeout       time.Duration = 10 * time.Second
+	DefaultIdempotencyTTL time.Duration = 24 * time.Hour
+	DefaultReloadInterval time.Duration = 5 * time.Second
+	DefaultCORSMaxAge     time.Duration = 10 * time.Minute
 )
 
 // file paths.
@@ -60,12 +154,25 @@ const (
 	CouponBase2  = "couponbase2"
 	CouponBase3  = "couponbase3"
 	EnvFile      = ".env"
+	CouponIndex  = "coupons.idx"
+	CouponRules  = "coupon_rules.json"
+	OrdersLog    = "orders.log"
+	Traces       = "traces.jsonl"
+	Categories   = "categories.json"
 )
 
+// CompressedFileExts: extensions tried, in order, when looking up a data file that may be compressed.
+var CompressedFileExts = []string{"", ".gz", ".zst"}
+
 var (
 	ProductsFilePath = fmt.Sprintf("%s/%s", DataDir, ProductsFile)
 	CouponFilePath1  = fmt.Sprintf("%s/%s", DataDir, CouponBase1)
 	CouponFilePath2  = fmt.Sprintf("%s/%s", DataDir, CouponBase2)
 	CouponFilePath3  = fmt.Sprintf("%s/%s", DataDir, CouponBase3)
 	EnvFilePath      = fmt.Sprintf("%s/%s", DataDir, EnvFile)
+	CouponIndexFile  = fmt.Sprintf("%s/%s", DataDir, CouponIndex)
+	CouponRulesPath  = fmt.Sprintf("%s/%s", DataDir, CouponRules)
+	OrdersLogFile    = fmt.Sprintf("%s/%s", DataDir, OrdersLog)
+	TracesFile       = fmt.Sprintf("%s/%s", DataDir, Traces)
+	CategoriesPath   = fmt.Sprintf("%s/%s", DataDir, Categories)
 )
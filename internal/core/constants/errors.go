@@ -9,15 +9,82 @@ var (
 	ErrReadingJSONfile     = errors.New("error reading json file")
 	ErrUnmarshallingData   = errors.New("error occurred when unmarshalling data")
 	ErrWritingResponse     = errors.New("error occurred when writing response")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrInvalidProduct      = errors.New("invalid product")
+	ErrProductExists       = errors.New("a product with this id already exists")
+	ErrInvalidProductSort  = errors.New("sort must be one of id, name or price")
+	ErrInvalidPriceFilter  = errors.New("minPrice and maxPrice must be amounts")
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrInvalidPagination   = errors.New("offset and limit must not be negative")
+	ErrInsufficientStock   = errors.New("not enough stock to fulfil the order")
+	ErrUnknownOrderProduct = errors.New("order item refers to an unknown product")
+)
+
+// request errors
+var (
+	ErrMalformedRequest = errors.New("request body is not valid JSON")
+	ErrRequestTooLarge  = errors.New("request body is too large")
+	ErrInvalidProductID = errors.New("product id must be a number")
+	ErrTooManyRequests  = errors.New("too many requests, retry later")
+)
+
+// order status errors
+var (
+	ErrInvalidOrderStatus      = errors.New("unknown order status")
+	ErrIllegalStatusTransition = errors.New("order cannot move to the requested status")
 )
 
 // validation errors
 var (
-	ErrNoItemsInOrderReqd = errors.New("items required for the order request")
-	ErrProductItemReqd    = errors.New("productId cannot be empty")
-	ErrProductQtyReqd     = errors.New("product quantity required")
+	ErrValidationFailed = errors.New("validation failed")
+
+	ErrNoItemsInOrderReqd   = errors.New("items required for the order request")
+	ErrTooManyOrderItems    = errors.New("too many items in the order request")
+	ErrProductItemReqd      = errors.New("productId cannot be empty")
+	ErrNonNumericProductID  = errors.New("productId must be numeric")
+	ErrProductIDLeadingZero = errors.New("productId must not have leading zeros")
+	ErrProductQtyReqd       = errors.New("product quantity required")
+	ErrProductQtyTooLarge   = errors.New("product quantity too large")
+	ErrDuplicateOrderItem   = errors.New("item repeats an earlier item")
+	ErrModifierReqd         = errors.New("modifiers need a groupId and an optionId")
+
+	ErrInvalidModifierSelection = errors.New("invalid modifier selection")
 
 	ErrEmptyPromoCode         = errors.New("promo code cannot be empty")
 	ErrInvalidPromoCodeLength = errors.New("invalid promo code length")
 	ErrInvalidPromoCode       = errors.New("invalid promo code")
 )
+
+// pricing errors
+var (
+	ErrUnknownDiscountRule = errors.New("unknown discount rule type")
+	ErrInvalidDiscountRule = errors.New("invalid discount rule")
+	ErrCurrencyMismatch    = errors.New("order items are priced in different currencies")
+	ErrInvalidCurrency     = errors.New("invalid or unknown currency")
+	ErrInvalidMoneyAmount  = errors.New("invalid money amount")
+)
+
+// config errors
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// coupon index errors
+var (
+	ErrInvalidCouponIndex   = errors.New("invalid coupon index file")
+	ErrTooManyCouponSources = errors.New("coupon index supports 1 to 32 coupon sources")
+)
+
+// idempotency errors
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInUse   = errors.New("a request with this idempotency key is still in progress")
+	ErrInvalidIdempotencyKey = errors.New("idempotency key must be 1 to 255 characters")
+)
+
+// auth errors
+var (
+	ErrMissingAPIKey  = errors.New("missing API key")
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrAPIKeyExpired  = errors.New("API key expired")
+	ErrMissingScope   = errors.New("API key does not have the required scope")
+	ErrInvalidAPIKeys = errors.New("invalid API keys file")
+)
@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+type Scope string
+
+const (
+	ScopeProductsRead Scope = "products:read"
+	ScopeOrdersRead   Scope = "orders:read"
+	ScopeOrdersWrite  Scope = "orders:write"
+	ScopeAdmin        Scope = "admin"
+)
+
+var Scopes = []Scope{ScopeProductsRead, ScopeOrdersRead, ScopeOrdersWrite, ScopeAdmin}
+
+// APIKey: a client of the api. Only the SHA-256 Hash of the key is kept. A nil ExpiresAt never expires.
+type APIKey struct {
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash"`
+	Scopes    []Scope    `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of key, as stored in APIKey.Hash.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// HasScope: the admin scope grants every scope.
+func (k APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeAdmin)
+}
+
+func (k APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type Category struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	SortOrder int    `json:"sortOrder"`
+	Image     string `json:"image,omitempty"`
+}
+
+// CategoryDetails: optional display details of a category, keyed by the category name used by the products.
+type CategoryDetails struct {
+	DisplayName string `json:"displayName"`
+	SortOrder   *int   `json:"sortOrder"`
+	Image       string `json:"image"`
+}
+
+// CategoryID turns a category name into a URL friendly id, e.g. "Crème Brûlée" into "creme-brulee".
+func CategoryID(name string) string {
+	var id strings.Builder
+
+	dash := false
+
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && id.Len() > 0 {
+				id.WriteByte('-')
+			}
+
+			id.WriteRune(r)
+
+			dash = false
+		default:
+			dash = true
+		}
+	}
+
+	return id.String()
+}
+
+// DeriveCategories lists the categories used by products, in the order they first appear unless details give
+// a sort order. Products are expected in catalog (id) order.
+func DeriveCategories(products []Product, details map[string]CategoryDetails) []Category {
+	categories := []Category{}
+	seen := map[string]bool{}
+
+	for _, product := range products {
+		if product.Category == "" || seen[product.Category] {
+			continue
+		}
+
+		seen[product.Category] = true
+
+		category := Category{
+			ID:        CategoryID(product.Category),
+			Name:      product.Category,
+			SortOrder: len(categories) + 1,
+		}
+
+		if detail, found := details[product.Category]; found {
+			if detail.DisplayName != "" {
+				category.Name = detail.DisplayName
+			}
+
+			if detail.SortOrder != nil {
+				category.SortOrder = *detail.SortOrder
+			}
+
+			category.Image = detail.Image
+		}
+
+		categories = append(categories, category)
+	}
+
+	slices.SortStableFunc(categories, func(a, b Category) int {
+		return a.SortOrder - b.SortOrder
+	})
+
+	return categories
+}
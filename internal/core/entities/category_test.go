@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCategoryID(t *testing.T) {
+	tests := map[string]string{
+		"Waffle":        "waffle",
+		"Crème Brûlée":  "creme-brulee",
+		"Panna  Cotta!": "panna-cotta",
+	}
+
+	for name, expected := range tests {
+		if id := CategoryID(name); id != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, id)
+		}
+	}
+}
+
+func TestDeriveCategories(t *testing.T) {
+	first := 0
+
+	products := []Product{
+		{ID: "1", Category: "Waffle"},
+		{ID: "2", Category: "Crème Brûlée"},
+		{ID: "3", Category: "Waffle"},
+		{ID: "4", Category: "Tiramisu"},
+	}
+
+	details := map[string]CategoryDetails{
+		"Tiramisu": {DisplayName: "Tiramisù", SortOrder: &first, Image: "/images/tiramisu.jpg"},
+	}
+
+	categories := DeriveCategories(products, details)
+
+	ids := []string{}
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+
+	if !slices.Equal(ids, []string{"tiramisu", "waffle", "creme-brulee"}) {
+		t.Errorf("unexpected category order: %v", ids)
+	}
+
+	if categories[0].Name != "Tiramisù" || categories[0].Image != "/images/tiramisu.jpg" {
+		t.Errorf("expected the category details to apply: %+v", categories[0])
+	}
+}
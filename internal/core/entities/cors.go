@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// CORSPolicy: AllowedOrigins are exact origins such as "https://shop.example.com", subdomain wildcards such as
+// "https://*.example.com", or "*" for any origin. With AllowCredentials only the exact origins are allowed.
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
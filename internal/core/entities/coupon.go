@@ -0,0 +1,25 @@
+package entities
+
+import "strings"
+
+// CouponSource: a named coupon file, a coupon code is valid when enough sources contain it.
+type CouponSource struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// NormalizeCouponCode: a coupon code as read from a coupon source, in the form compared with order coupon codes.
+// Surrounding whitespace, such as the carriage return of files with CRLF line endings, is not part of the code.
+func NormalizeCouponCode(line string) string {
+	return strings.TrimSpace(line)
+}
+
+// CouponCheckResult: the outcome of checking the coupon code of an order.
+type CouponCheckResult string
+
+const (
+	CouponValid       CouponCheckResult = "valid"
+	CouponInvalid     CouponCheckResult = "invalid"
+	CouponLengthError CouponCheckResult = "length_error"
+	CouponCheckError  CouponCheckResult = "error"
+)
@@ -0,0 +1,19 @@
+package entities
+
+type DiscountType string
+
+const (
+	DiscountPercentage   DiscountType = "percentage"
+	DiscountFixedAmount  DiscountType = "fixed"
+	DiscountFreeCheapest DiscountType = "free_cheapest"
+	DiscountCategory     DiscountType = "category"
+)
+
+// DiscountRule: what a valid coupon code takes off the order.
+// Percent is used by percentage and category rules, Amount by fixed rules and Category by category rules.
+type DiscountRule struct {
+	Type     DiscountType `json:"type"`
+	Percent  float64      `json:"percent,omitempty"`
+	Amount   Money        `json:"amount"`
+	Category string       `json:"category,omitempty"`
+}
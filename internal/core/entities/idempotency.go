@@ -0,0 +1,8 @@
+package entities
+
+// IdempotentResponse: the response recorded for an Idempotency-Key, replayed for retries of the same request.
+type IdempotentResponse struct {
+	Status int
+	Header map[string][]string
+	Body   []byte
+}
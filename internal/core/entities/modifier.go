@@ -0,0 +1,138 @@
+package entities
+
+import (
+	"fmt"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+// ModifierGroup: a choice a customer makes for a product, e.g. "Size" or "Extra toppings".
+// Between MinSelect and MaxSelect different options of the group are selected per order item.
+type ModifierGroup struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	MinSelect int              `json:"minSelect"`
+	MaxSelect int              `json:"maxSelect"`
+	Options   []ModifierOption `json:"options"`
+}
+
+// ModifierOption: PriceDelta is added to the unit price of the product when the option is selected.
+type ModifierOption struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	PriceDelta Money  `json:"priceDelta"`
+}
+
+type SelectedModifier struct {
+	GroupID  string `json:"groupId"`
+	OptionID string `json:"optionId"`
+}
+
+// OrderLineModifier: a selected option as it was priced when the order was placed.
+type OrderLineModifier struct {
+	GroupID    string `json:"groupId"`
+	OptionID   string `json:"optionId"`
+	Name       string `json:"name"`
+	PriceDelta Money  `json:"priceDelta"`
+}
+
+func (g ModifierGroup) option(optionID string) (ModifierOption, bool) {
+	for _, option := range g.Options {
+		if option.ID == optionID {
+			return option, true
+		}
+	}
+
+	return ModifierOption{}, false
+}
+
+// validateModifierGroups checks the modifier groups of a product: unique ids, a satisfiable selection range
+// and non-negative price deltas in the currency of the product.
+func (p Product) validateModifierGroups() error {
+	groupIDs := map[string]bool{}
+
+	for _, group := range p.Modifiers {
+		if group.ID == "" || groupIDs[group.ID] {
+			return fmt.Errorf("%w: modifier group ids must be unique and not empty", constants.ErrInvalidProduct)
+		}
+
+		groupIDs[group.ID] = true
+
+		if group.MinSelect < 0 || group.MaxSelect < group.MinSelect || group.MaxSelect < 1 || group.MaxSelect > len(group.Options) {
+			return fmt.Errorf("%w: modifier group %s: selection must be between 0 and the number of options", constants.ErrInvalidProduct, group.ID)
+		}
+
+		optionIDs := map[string]bool{}
+
+		for _, option := range group.Options {
+			if option.ID == "" || optionIDs[option.ID] {
+				return fmt.Errorf("%w: modifier group %s: option ids must be unique and not empty", constants.ErrInvalidProduct, group.ID)
+			}
+
+			optionIDs[option.ID] = true
+
+			if option.PriceDelta.Amount < 0 || !option.PriceDelta.SameCurrency(p.Price) {
+				return fmt.Errorf("%w: modifier option %s: price delta must not be negative and in %s",
+					constants.ErrInvalidProduct, option.ID, p.Price.Currency)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ResolveModifiers checks the selected modifiers against the modifier groups of the product
+// and returns them with their names and price deltas, in the order they were selected.
+func (p Product) ResolveModifiers(selected []SelectedModifier) ([]OrderLineModifier, error) {
+	resolved := make([]OrderLineModifier, 0, len(selected))
+	counts := map[string]int{}
+	seen := map[SelectedModifier]bool{}
+
+	for _, selection := range selected {
+		group, found := p.modifierGroup(selection.GroupID)
+		if !found {
+			return nil, fmt.Errorf("%w: product %s has no modifier group %q",
+				constants.ErrInvalidModifierSelection, p.ID, selection.GroupID)
+		}
+
+		option, found := group.option(selection.OptionID)
+		if !found {
+			return nil, fmt.Errorf("%w: modifier group %s has no option %q",
+				constants.ErrInvalidModifierSelection, group.ID, selection.OptionID)
+		}
+
+		if seen[selection] {
+			return nil, fmt.Errorf("%w: option %s of %s selected more than once",
+				constants.ErrInvalidModifierSelection, option.ID, group.ID)
+		}
+
+		seen[selection] = true
+		counts[group.ID]++
+
+		resolved = append(resolved, OrderLineModifier{
+			GroupID:    group.ID,
+			OptionID:   option.ID,
+			Name:       option.Name,
+			PriceDelta: option.PriceDelta,
+		})
+	}
+
+	for _, group := range p.Modifiers {
+		if count := counts[group.ID]; count < group.MinSelect || count > group.MaxSelect {
+			return nil, fmt.Errorf("%w: select %d to %d options of %s for product %s",
+				constants.ErrInvalidModifierSelection, group.MinSelect, group.MaxSelect, group.Name, p.ID)
+		}
+	}
+
+	return resolved, nil
+}
+
+func (p Product) modifierGroup(groupID string) (ModifierGroup, bool) {
+	for _, group := range p.Modifiers {
+		if group.ID == groupID {
+			return group, true
+		}
+	}
+
+	return ModifierGroup{}, false
+}
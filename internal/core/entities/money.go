@@ -0,0 +1,320 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+type RoundingMode string
+
+const (
+	RoundHalfUp   RoundingMode = "half_up"
+	RoundHalfEven RoundingMode = "half_even"
+	RoundDown     RoundingMode = "down"
+	RoundUp       RoundingMode = "up"
+)
+
+// Currency: MinorUnits is the number of decimals of the currency, Rounding is how fractions of a minor unit are
+// rounded and Increment is the smallest amount a total can be, in minor units (e.g. 5 for cash rounding to 0.05).
+type Currency struct {
+	Code       string
+	MinorUnits int
+	Rounding   RoundingMode
+	Increment  int64
+}
+
+var (
+	currencyMu      sync.RWMutex
+	defaultCurrency = "USD"
+	currencies      = map[string]Currency{
+		"AUD": {Code: "AUD", MinorUnits: 2, Rounding: RoundHalfUp, Increment: 1},
+		"CHF": {Code: "CHF", MinorUnits: 2, Rounding: RoundHalfUp, Increment: 5},
+		"EUR": {Code: "EUR", MinorUnits: 2, Rounding: RoundHalfEven, Increment: 1},
+		"GBP": {Code: "GBP", MinorUnits: 2, Rounding: RoundHalfUp, Increment: 1},
+		"JPY": {Code: "JPY", MinorUnits: 0, Rounding: RoundHalfUp, Increment: 1},
+		"NZD": {Code: "NZD", MinorUnits: 2, Rounding: RoundHalfUp, Increment: 1},
+		"USD": {Code: "USD", MinorUnits: 2, Rounding: RoundHalfUp, Increment: 1},
+	}
+)
+
+// RegisterCurrency adds a currency or replaces the rounding rules of a known one.
+func RegisterCurrency(currency Currency) error {
+	if len(currency.Code) != 3 || currency.MinorUnits < 0 || currency.MinorUnits > 4 || currency.Increment < 1 {
+		return constants.ErrInvalidCurrency
+	}
+
+	switch currency.Rounding {
+	case RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+	default:
+		return constants.ErrInvalidCurrency
+	}
+
+	currencyMu.Lock()
+	defer currencyMu.Unlock()
+
+	currencies[strings.ToUpper(currency.Code)] = currency
+
+	return nil
+}
+
+// SetDefaultCurrency sets the currency of amounts that don't name one, such as the prices in products.json.
+func SetDefaultCurrency(code string) error {
+	code = strings.ToUpper(code)
+
+	currencyMu.Lock()
+	defer currencyMu.Unlock()
+
+	if _, found := currencies[code]; !found {
+		return constants.ErrInvalidCurrency
+	}
+
+	defaultCurrency = code
+
+	return nil
+}
+
+func DefaultCurrency() string {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+
+	return defaultCurrency
+}
+
+func LookupCurrency(code string) (Currency, bool) {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+
+	if code == "" {
+		code = defaultCurrency
+	}
+
+	currency, found := currencies[strings.ToUpper(code)]
+
+	return currency, found
+}
+
+// Money: an amount in the minor units of its currency, e.g. 650 USD is $6.50.
+// It marshals to a plain JSON number (6.50) so price fields stay backward compatible.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney creates an amount of minor units. An empty currency means the default currency.
+func NewMoney(amount int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency()
+	}
+
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+func (m Money) currency() Currency {
+	currency, found := LookupCurrency(m.Currency)
+	if !found {
+		return Currency{Code: m.Currency, MinorUnits: 2, Rounding: RoundHalfUp, Increment: 1}
+	}
+
+	return currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == other.Currency
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Percent returns percent % of m, rounded to a minor unit with the currency rounding mode.
+// The percentage is applied in basis points so 12.5% stays exact.
+func (m Money) Percent(percent float64) Money {
+	basisPoints := int64(percent*100 + 0.5)
+
+	return Money{Amount: divRound(m.Amount*basisPoints, 10_000, m.currency().Rounding), Currency: m.Currency}
+}
+
+// Round rounds m to the currency increment, e.g. to 0.05 for cash rounded currencies.
+func (m Money) Round() Money {
+	currency := m.currency()
+
+	if currency.Increment <= 1 {
+		return m
+	}
+
+	return Money{Amount: divRound(m.Amount, currency.Increment, currency.Rounding) * currency.Increment, Currency: m.Currency}
+}
+
+func (m Money) Less(other Money) bool {
+	return m.Amount < other.Amount
+}
+
+func MinMoney(a, b Money) Money {
+	if b.Less(a) {
+		return b
+	}
+
+	return a
+}
+
+// String formats m as a decimal, e.g. "6.50".
+func (m Money) String() string {
+	minorUnits := m.currency().MinorUnits
+
+	amount := m.Amount
+	sign := ""
+
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if minorUnits == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= minorUnits {
+		digits = strings.Repeat("0", minorUnits-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-minorUnits] + "." + digits[len(digits)-minorUnits:]
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a decimal number in the default currency, e.g. 6.5,
+// or an object of minor units and currency, e.g. {"amount": 650, "currency": "USD"}.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("{")) {
+		var obj struct {
+			Amount   int64  `json:"amount"`
+			Currency string `json:"currency"`
+		}
+
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+
+		*m = NewMoney(obj.Amount, obj.Currency)
+
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+
+	parsed, err := ParseMoney(number.String(), "")
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+// ParseMoney parses a decimal amount such as "6.5" without going through float64.
+// Digits beyond the minor units of the currency are rounded with the currency rounding mode.
+func ParseMoney(value, currencyCode string) (Money, error) {
+	money := NewMoney(0, currencyCode)
+	currency := money.currency()
+
+	value = strings.TrimSpace(value)
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return Money{}, constants.ErrInvalidMoneyAmount
+	}
+
+	if whole == "" {
+		whole = "0"
+	}
+
+	extra := max(0, len(fraction)-currency.MinorUnits)
+	fraction += strings.Repeat("0", max(0, currency.MinorUnits-len(fraction)))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || strings.ContainsAny(whole+fraction, "+-eE") {
+		return Money{}, constants.ErrInvalidMoneyAmount
+	}
+
+	divisor := int64(1)
+	for range extra {
+		divisor *= 10
+	}
+
+	money.Amount = divRound(amount, divisor, currency.Rounding)
+
+	if negative {
+		money.Amount = -money.Amount
+	}
+
+	return money, nil
+}
+
+// divRound divides n by d (d > 0) rounding the remainder with mode.
+func divRound(n, d int64, mode RoundingMode) int64 {
+	if d == 1 {
+		return n
+	}
+
+	quotient, remainder := n/d, n%d
+	if remainder == 0 {
+		return quotient
+	}
+
+	sign := int64(1)
+	if n < 0 {
+		sign, remainder = -1, -remainder
+	}
+
+	roundAway := false
+
+	switch mode {
+	case RoundDown:
+		roundAway = false
+	case RoundUp:
+		roundAway = true
+	case RoundHalfEven:
+		roundAway = remainder*2 > d || (remainder*2 == d && quotient%2 != 0)
+	default:
+		roundAway = remainder*2 >= d
+	}
+
+	if roundAway {
+		return quotient + sign
+	}
+
+	return quotient
+}
@@ -0,0 +1,76 @@
+package entities
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoney_JSONBackwardCompatible(t *testing.T) {
+	var product Product
+
+	if err := json.Unmarshal([]byte(`{"id":"1","price":6.5}`), &product); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if product.Price != NewMoney(650, "USD") {
+		t.Errorf("expected 650 USD minor units, got %+v", product.Price)
+	}
+
+	out, err := json.Marshal(product.Price)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(out) != "6.50" {
+		t.Errorf("expected 6.50, got %s", out)
+	}
+}
+
+func TestMoney_Rounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    Money
+		percent  float64
+		expected int64
+	}{
+		{name: "half up", money: NewMoney(1025, "USD"), percent: 10, expected: 103},
+		{name: "half even rounds to even", money: NewMoney(1025, "EUR"), percent: 10, expected: 102},
+		{name: "zero decimals", money: NewMoney(1250, "JPY"), percent: 18, expected: 225},
+		{name: "fractional percent", money: NewMoney(1000, "USD"), percent: 12.5, expected: 125},
+	}
+
+	for _, tc := range tests {
+		if got := tc.money.Percent(tc.percent).Amount; got != tc.expected {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.expected, got)
+		}
+	}
+
+	if got := NewMoney(1023, "CHF").Round().Amount; got != 1025 {
+		t.Errorf("cash rounding: expected 1025, got %d", got)
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := map[string]int64{
+		"7":      700,
+		"6.5":    650,
+		"0.125":  13,
+		"-1.05":  -105,
+		"19.999": 2000,
+	}
+
+	for value, expected := range tests {
+		money, err := ParseMoney(value, "USD")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", value, err)
+		}
+
+		if money.Amount != expected {
+			t.Errorf("%s: expected %d, got %d", value, expected, money.Amount)
+		}
+	}
+
+	if _, err := ParseMoney("abc", "USD"); err == nil {
+		t.Errorf("expected an error parsing abc")
+	}
+}
@@ -1,20 +1,57 @@
 package entities
 
 import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
 )
 
 type Order struct {
-	ID       string      `json:"id"`
-	Items    []OrderItem `json:"items"`
-	Products []Product   `json:"products"`
+	ID            string         `json:"id"`
+	Items         []OrderItem    `json:"items"`
+	Products      []Product      `json:"products"`
+	Lines         []OrderLine    `json:"lines"`
+	CouponCode    string         `json:"couponCode,omitempty"`
+	Currency      string         `json:"currency"`
+	Subtotal      Money          `json:"subtotal"`
+	Discount      Money          `json:"discount"`
+	Rounding      Money          `json:"rounding"`
+	Total         Money          `json:"total"`
+	Status        OrderStatus    `json:"status"`
+	StatusHistory []StatusChange `json:"statusHistory"`
+	CreatedAt     time.Time      `json:"createdAt"`
+}
+
+// OrderPage: a page of orders, most recent first. Total is the number of orders across all pages
+// and Next links to the following page, if any.
+type OrderPage struct {
+	Orders []Order `json:"orders"`
+	Total  int     `json:"total"`
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+	Next   string  `json:"next,omitempty"`
+}
+
+// OrderLine: UnitPrice is the product price plus the price deltas of the selected modifiers.
+type OrderLine struct {
+	ProductID string              `json:"productId"`
+	Name      string              `json:"name"`
+	Category  string              `json:"category"`
+	Modifiers []OrderLineModifier `json:"modifiers,omitempty"`
+	UnitPrice Money               `json:"unitPrice"`
+	Quantity  int                 `json:"quantity"`
+	LineTotal Money               `json:"lineTotal"`
 }
 
 type OrderItem struct {
-	ProductID string `json:"productId"`
-	Quantity  int    `json:"quantity"`
+	ProductID string             `json:"productId"`
+	Quantity  int                `json:"quantity"`
+	Modifiers []SelectedModifier `json:"modifiers,omitempty"`
 }
 
 type OrderReq struct {
@@ -22,18 +59,109 @@ type OrderReq struct {
 	CouponCode string      `json:"couponCode"`
 }
 
+// Validate checks the structure of the request and returns every violation found as ValidationErrors.
 func (or OrderReq) Validate() error {
-	if len(or.Items) == 0 {
-		return constants.ErrNoItemsInOrderReqd
+	var errs ValidationErrors
+
+	switch {
+	case len(or.Items) == 0:
+		errs.Add("items", constants.ErrNoItemsInOrderReqd)
+	case len(or.Items) > constants.MaxOrderItems:
+		errs.Add("items", fmt.Errorf("%w: at most %d", constants.ErrTooManyOrderItems, constants.MaxOrderItems))
 	}
 
-	for _, item := range or.Items {
+	firstIndex := map[string]int{}
+
+	for i, item := range or.Items {
+		field := fmt.Sprintf("items[%d]", i)
+
+		switch {
+		case item.ProductID == "":
+			errs.Add(field+".productId", constants.ErrProductItemReqd)
+		case !isNumeric(item.ProductID):
+			errs.Add(field+".productId", constants.ErrNonNumericProductID)
+		case len(item.ProductID) > 1 && item.ProductID[0] == '0':
+			errs.Add(field+".productId", constants.ErrProductIDLeadingZero)
+		}
+
+		switch {
+		case item.Quantity <= 0:
+			errs.Add(field+".quantity", constants.ErrProductQtyReqd)
+		case item.Quantity > constants.MaxItemQuantity:
+			errs.Add(field+".quantity", fmt.Errorf("%w: at most %d", constants.ErrProductQtyTooLarge, constants.MaxItemQuantity))
+		}
+
+		for j, modifier := range item.Modifiers {
+			if modifier.GroupID == "" || modifier.OptionID == "" {
+				errs.Add(fmt.Sprintf("%s.modifiers[%d]", field, j), constants.ErrModifierReqd)
+			}
+		}
+
 		if item.ProductID == "" {
-			return constants.ErrProductItemReqd
+			continue
+		}
+
+		if first, found := firstIndex[item.key()]; found {
+			errs.Add(field, fmt.Errorf("%w, items[%d]: combine their quantities", constants.ErrDuplicateOrderItem, first))
+		} else {
+			firstIndex[item.key()] = i
 		}
+	}
+
+	if codeLength := utf8.RuneCountInString(or.CouponCode); codeLength > 0 && (codeLength < constants.MinCouponCodeLen || codeLength > constants.MaxCouponCodeLen) {
+		errs.Add("couponCode", constants.ErrInvalidPromoCodeLength)
+	}
+
+	return errs.Err()
+}
+
+// key identifies the items that are the same product with the same modifiers, regardless of the modifier order.
+func (item OrderItem) key() string {
+	modifiers := make([]string, 0, len(item.Modifiers))
+
+	for _, modifier := range item.Modifiers {
+		modifiers = append(modifiers, modifier.GroupID+"="+modifier.OptionID)
+	}
+
+	slices.Sort(modifiers)
+
+	return canonicalProductID(item.ProductID) + "|" + strings.Join(modifiers, ",")
+}
+
+// canonicalProductID: a numeric product id without leading zeros, as products are looked up by number and "01"
+// names the same product as "1". Other ids are returned as they are.
+func canonicalProductID(productID string) string {
+	if !isNumeric(productID) {
+		return productID
+	}
+
+	if id, err := strconv.ParseUint(productID, 10, 64); err == nil {
+		return strconv.FormatUint(id, 10)
+	}
+
+	return productID
+}
+
+func isNumeric(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return value != ""
+}
+
+// ValidateModifiers checks the modifiers selected for every item against the catalog.
+// products must be in the same order as the items.
+func (or OrderReq) ValidateModifiers(products []Product) error {
+	if len(products) != len(or.Items) {
+		return constants.ErrProductNotFound
+	}
 
-		if item.Quantity <= 0 {
-			return constants.ErrProductQtyReqd
+	for i, item := range or.Items {
+		if _, err := products[i].ResolveModifiers(item.Modifiers); err != nil {
+			return FieldError{Field: fmt.Sprintf("items[%d].modifiers", i), Err: err}
 		}
 	}
 
@@ -45,8 +173,8 @@ func (or OrderReq) ValidateCouponCode() error {
 		return constants.ErrEmptyPromoCode
 	}
 
-	if codeLength := utf8.RuneCountInString(or.CouponCode); codeLength < 8 || codeLength > 10 {
-		return constants.ErrInvalidPromoCodeLength
+	if codeLength := utf8.RuneCountInString(or.CouponCode); codeLength < constants.MinCouponCodeLen || codeLength > constants.MaxCouponCodeLen {
+		return FieldError{Field: "couponCode", Err: constants.ErrInvalidPromoCodeLength}
 	}
 
 	return nil
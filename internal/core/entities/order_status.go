@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"slices"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+type OrderStatus string
+
+const (
+	OrderPlaced    OrderStatus = "placed"
+	OrderAccepted  OrderStatus = "accepted"
+	OrderPreparing OrderStatus = "preparing"
+	OrderReady     OrderStatus = "ready"
+	OrderCompleted OrderStatus = "completed"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRejected  OrderStatus = "rejected"
+)
+
+// orderTransitions: the statuses an order can move to from each status.
+// completed, cancelled and rejected are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPlaced:    {OrderAccepted, OrderRejected, OrderCancelled},
+	OrderAccepted:  {OrderPreparing, OrderCancelled},
+	OrderPreparing: {OrderReady, OrderCancelled},
+	OrderReady:     {OrderCompleted},
+	OrderCompleted: {},
+	OrderCancelled: {},
+	OrderRejected:  {},
+}
+
+type StatusChange struct {
+	Status OrderStatus `json:"status"`
+	At     time.Time   `json:"at"`
+}
+
+type OrderStatusReq struct {
+	Status OrderStatus `json:"status"`
+}
+
+func (s OrderStatus) IsValid() bool {
+	_, found := orderTransitions[s]
+
+	return found
+}
+
+func (s OrderStatus) IsFinal() bool {
+	return len(orderTransitions[s]) == 0
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return slices.Contains(orderTransitions[s], next)
+}
+
+// TransitionTo moves the order to status and records when it happened.
+func (o *Order) TransitionTo(status OrderStatus, at time.Time) error {
+	if !status.IsValid() {
+		return constants.ErrInvalidOrderStatus
+	}
+
+	if !o.Status.CanTransitionTo(status) {
+		return constants.ErrIllegalStatusTransition
+	}
+
+	o.Status = status
+	o.StatusHistory = append(o.StatusHistory, StatusChange{Status: status, At: at})
+
+	return nil
+}
+
+// Place starts the lifecycle of a new order.
+func (o *Order) Place(at time.Time) {
+	o.CreatedAt = at
+	o.Status = OrderPlaced
+	o.StatusHistory = []StatusChange{{Status: OrderPlaced, At: at}}
+}
@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+func TestOrder_TransitionTo(t *testing.T) {
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	order := &Order{ID: "1"}
+	order.Place(at)
+
+	for i, status := range []OrderStatus{OrderAccepted, OrderPreparing, OrderReady, OrderCompleted} {
+		if err := order.TransitionTo(status, at.Add(time.Duration(i+1)*time.Minute)); err != nil {
+			t.Fatalf("transition to %s: unexpected error: %v", status, err)
+		}
+	}
+
+	if len(order.StatusHistory) != 5 || order.StatusHistory[4].Status != OrderCompleted {
+		t.Errorf("unexpected status history: %+v", order.StatusHistory)
+	}
+
+	if err := order.TransitionTo(OrderCancelled, at); !errors.Is(err, constants.ErrIllegalStatusTransition) {
+		t.Errorf("expected %v cancelling a completed order, got %v", constants.ErrIllegalStatusTransition, err)
+	}
+}
+
+func TestOrder_TransitionToInvalidStatus(t *testing.T) {
+	order := &Order{ID: "1"}
+	order.Place(time.Now())
+
+	if err := order.TransitionTo("eaten", time.Now()); !errors.Is(err, constants.ErrInvalidOrderStatus) {
+		t.Errorf("expected %v, got %v", constants.ErrInvalidOrderStatus, err)
+	}
+
+	if err := order.TransitionTo(OrderReady, time.Now()); !errors.Is(err, constants.ErrIllegalStatusTransition) {
+		t.Errorf("expected %v skipping preparing, got %v", constants.ErrIllegalStatusTransition, err)
+	}
+}
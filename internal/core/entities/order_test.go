@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+func TestOrderReq_Validate(t *testing.T) {
+	orderReq := OrderReq{
+		Items: []OrderItem{
+			{ProductID: "1", Quantity: 1, Modifiers: []SelectedModifier{{GroupID: "size", OptionID: "large"}}},
+			{ProductID: "", Quantity: 0},
+			{ProductID: "waffle", Quantity: constants.MaxItemQuantity + 1},
+			{ProductID: "1", Quantity: 2, Modifiers: []SelectedModifier{{GroupID: "size", OptionID: "large"}}},
+			{ProductID: "1", Quantity: 1, Modifiers: []SelectedModifier{{GroupID: "size", OptionID: "small"}}},
+			{ProductID: "2", Quantity: 1, Modifiers: []SelectedModifier{{GroupID: "size"}}},
+		},
+		CouponCode: "SHORT",
+	}
+
+	err := orderReq.Validate()
+	if !errors.Is(err, constants.ErrValidationFailed) {
+		t.Fatalf("expected %v, got %v", constants.ErrValidationFailed, err)
+	}
+
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %T", err)
+	}
+
+	expected := map[string]error{
+		"items[1].productId":    constants.ErrProductItemReqd,
+		"items[1].quantity":     constants.ErrProductQtyReqd,
+		"items[2].productId":    constants.ErrNonNumericProductID,
+		"items[2].quantity":     constants.ErrProductQtyTooLarge,
+		"items[3]":              constants.ErrDuplicateOrderItem,
+		"items[5].modifiers[0]": constants.ErrModifierReqd,
+		"couponCode":            constants.ErrInvalidPromoCodeLength,
+	}
+
+	fields := []string{}
+
+	for _, fieldErr := range errs {
+		fields = append(fields, fieldErr.Field)
+
+		if !errors.Is(fieldErr, expected[fieldErr.Field]) {
+			t.Errorf("%s: expected %v, got %v", fieldErr.Field, expected[fieldErr.Field], fieldErr.Err)
+		}
+	}
+
+	expectedFields := []string{
+		"items[1].productId", "items[1].quantity", "items[2].productId", "items[2].quantity",
+		"items[3]", "items[5].modifiers[0]", "couponCode",
+	}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("expected fields %v, got %v", expectedFields, fields)
+	}
+}
+
+func TestOrderReq_ValidateItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []OrderItem
+		expected error
+	}{
+		{"valid", []OrderItem{{ProductID: "1", Quantity: constants.MaxItemQuantity}}, nil},
+		{"no items", nil, constants.ErrNoItemsInOrderReqd},
+		{"too many items", make([]OrderItem, constants.MaxOrderItems+1), constants.ErrTooManyOrderItems},
+		{"leading zeros", []OrderItem{{ProductID: "01", Quantity: 1}}, constants.ErrProductIDLeadingZero},
+		{
+			"duplicate with modifiers in another order",
+			[]OrderItem{
+				{ProductID: "1", Quantity: 1, Modifiers: []SelectedModifier{{"size", "large"}, {"toppings", "nuts"}}},
+				{ProductID: "1", Quantity: 1, Modifiers: []SelectedModifier{{"toppings", "nuts"}, {"size", "large"}}},
+			},
+			constants.ErrDuplicateOrderItem,
+		},
+		{
+			"duplicate under an id with leading zeros",
+			[]OrderItem{{ProductID: "1", Quantity: 1}, {ProductID: "01", Quantity: 1}},
+			constants.ErrDuplicateOrderItem,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := OrderReq{Items: tt.items}.Validate()
+
+			if tt.expected == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
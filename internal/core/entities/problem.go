@@ -0,0 +1,20 @@
+package entities
+
+// Problem: an RFC 9457 problem details body. Code is the stable, machine readable error code clients match on,
+// Errors lists the offending fields of a request that failed validation.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []ProblemField `json:"errors,omitempty"`
+}
+
+// ProblemField: Field is the path of the offending field in the request body, e.g. items[2].quantity.
+type ProblemField struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
@@ -1,9 +1,121 @@
 // Package entities: defines all the model structures
 package entities
 
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+const (
+	DietaryVegetarian = "vegetarian"
+	DietaryVegan      = "vegan"
+	DietaryGlutenFree = "gluten-free"
+	DietaryDairyFree  = "dairy-free"
+	DietaryNutFree    = "nut-free"
+)
+
+var DietaryTags = []string{DietaryVegetarian, DietaryVegan, DietaryGlutenFree, DietaryDairyFree, DietaryNutFree}
+
+// Product: the metadata fields are optional so catalogs with only id, category, name and price still load.
+// Stock is the stock on hand in the catalog and the available stock in responses, nil when stock is not tracked.
+// Available is only set in responses.
 type Product struct {
-	ID       string  `json:"id"`
-	Category string  `json:"category"`
-	Name     string  `json:"name"`
-	Price    float64 `json:"price"`
+	ID          string          `json:"id"`
+	Category    string          `json:"category"`
+	Name        string          `json:"name"`
+	Price       Money           `json:"price"`
+	Description string          `json:"description,omitempty"`
+	Image       *ProductImage   `json:"image,omitempty"`
+	Allergens   []string        `json:"allergens,omitempty"`
+	Dietary     []string        `json:"dietary,omitempty"`
+	Calories    *int            `json:"calories,omitempty"`
+	Modifiers   []ModifierGroup `json:"modifiers,omitempty"`
+	Stock       *int            `json:"stock,omitempty"`
+	Available   *bool           `json:"available,omitempty"`
+}
+
+// ProductImage: image URLs of a product for each display size.
+type ProductImage struct {
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Mobile    string `json:"mobile,omitempty"`
+	Tablet    string `json:"tablet,omitempty"`
+	Desktop   string `json:"desktop,omitempty"`
+}
+
+// ValidateMetadata checks the optional metadata: known dietary tags, non-negative calories and stock,
+// and the modifier groups.
+func (p Product) ValidateMetadata() error {
+	for _, tag := range p.Dietary {
+		if !slices.Contains(DietaryTags, tag) {
+			return fmt.Errorf("%w: unknown dietary tag %q", constants.ErrInvalidProduct, tag)
+		}
+	}
+
+	if p.Calories != nil && *p.Calories < 0 {
+		return fmt.Errorf("%w: calories must not be negative", constants.ErrInvalidProduct)
+	}
+
+	if p.Stock != nil && *p.Stock < 0 {
+		return fmt.Errorf("%w: stock must not be negative", constants.ErrInvalidProduct)
+	}
+
+	return p.validateModifierGroups()
+}
+
+// ProductPatch: the product fields to change, nil fields are left as they are.
+type ProductPatch struct {
+	Category    *string          `json:"category"`
+	Name        *string          `json:"name"`
+	Price       *Money           `json:"price"`
+	Description *string          `json:"description"`
+	Image       *ProductImage    `json:"image"`
+	Allergens   *[]string        `json:"allergens"`
+	Dietary     *[]string        `json:"dietary"`
+	Calories    *int             `json:"calories"`
+	Modifiers   *[]ModifierGroup `json:"modifiers"`
+	Stock       *int             `json:"stock"`
+}
+
+func (p ProductPatch) Apply(product *Product) {
+	if p.Category != nil {
+		product.Category = *p.Category
+	}
+
+	if p.Name != nil {
+		product.Name = *p.Name
+	}
+
+	if p.Price != nil {
+		product.Price = *p.Price
+	}
+
+	if p.Description != nil {
+		product.Description = *p.Description
+	}
+
+	if p.Image != nil {
+		product.Image = p.Image
+	}
+
+	if p.Allergens != nil {
+		product.Allergens = *p.Allergens
+	}
+
+	if p.Dietary != nil {
+		product.Dietary = *p.Dietary
+	}
+
+	if p.Calories != nil {
+		product.Calories = p.Calories
+	}
+
+	if p.Modifiers != nil {
+		product.Modifiers = *p.Modifiers
+	}
+
+	if p.Stock != nil {
+		product.Stock = p.Stock
+	}
 }
@@ -0,0 +1,38 @@
+package entities
+
+type ProductSort string
+
+const (
+	SortByID    ProductSort = "id"
+	SortByName  ProductSort = "name"
+	SortByPrice ProductSort = "price"
+)
+
+// ProductQuery: the filters, sort order and page of a product listing. Zero values don't filter,
+// products are sorted by id by default and a zero Limit returns every matching product.
+type ProductQuery struct {
+	Category   string
+	MinPrice   *Money
+	MaxPrice   *Money
+	Search     string
+	Sort       ProductSort
+	Descending bool
+	Offset     int
+	Limit      int
+}
+
+// ProductPage: a page of products. Total is the number of matching products across all pages.
+type ProductPage struct {
+	Products []Product
+	Total    int
+	Offset   int
+	Limit    int
+}
+
+// PageMeta: pagination details sent next to a page of data. Next links to the following page, if any.
+type PageMeta struct {
+	Total  int    `json:"total"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Next   string `json:"next,omitempty"`
+}
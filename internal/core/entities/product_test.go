@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+func TestProductUnmarshalWithoutMetadata(t *testing.T) {
+	var product Product
+
+	if err := json.Unmarshal([]byte(`{"id": "1", "name": "Waffle with Berries", "category": "Waffle", "price": 6.5}`), &product); err != nil {
+		t.Fatal(err)
+	}
+
+	if product.Image != nil || product.Calories != nil || product.Allergens != nil {
+		t.Errorf("expected no metadata, got %+v", product)
+	}
+
+	data, err := json.Marshal(product)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"id":"1","category":"Waffle","name":"Waffle with Berries","price":6.50}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestProductValidateMetadata(t *testing.T) {
+	calories, negative := 300, -1
+
+	tests := []struct {
+		name    string
+		product Product
+		valid   bool
+	}{
+		{name: "no metadata", product: Product{}, valid: true},
+		{name: "known tags", product: Product{Dietary: []string{DietaryVegan, DietaryGlutenFree}, Calories: &calories}, valid: true},
+		{name: "unknown tag", product: Product{Dietary: []string{"keto"}}},
+		{name: "negative calories", product: Product{Calories: &negative}},
+	}
+
+	for _, test := range tests {
+		err := test.product.ValidateMetadata()
+
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+
+		if !test.valid && !errors.Is(err, constants.ErrInvalidProduct) {
+			t.Errorf("%s: expected ErrInvalidProduct, got %v", test.name, err)
+		}
+	}
+}
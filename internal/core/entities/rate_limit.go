@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// RateLimit: a client may make Requests requests Per period, in bursts of up to Requests.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// RateLimitDecision: Remaining is the number of requests the client can still make right away,
+// Reset the time until its full limit is available again and RetryAfter, for a rejected request,
+// the time until the next request is allowed.
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
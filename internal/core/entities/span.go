@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+// SpanStatus: whether the operation a span covers failed.
+type SpanStatus string
+
+const (
+	SpanOK    SpanStatus = "ok"
+	SpanError SpanStatus = "error"
+)
+
+// SpanRecord: a finished span as it is exported. Ids are lowercase hex, as in the W3C traceparent header.
+type SpanRecord struct {
+	TraceID      string         `json:"traceId"`
+	SpanID       string         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId,omitempty"`
+	Name         string         `json:"name"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	DurationMs   float64        `json:"durationMs"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       SpanStatus     `json:"status"`
+	Error        string         `json:"error,omitempty"`
+}
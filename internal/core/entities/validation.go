@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"strings"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+// FieldError: a validation error of the request field at path Field. It unwraps to the error of the field.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors: every violation found validating a request, in the order they were found.
+// It matches constants.ErrValidationFailed as well as the error of each field.
+type ValidationErrors []FieldError
+
+// Add records a violation of the field at path field.
+func (e *ValidationErrors) Add(field string, err error) {
+	*e = append(*e, FieldError{Field: field, Err: err})
+}
+
+// Err returns the violations as an error, nil when there are none.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Error())
+	}
+
+	return constants.ErrValidationFailed.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e)+1)
+	errs = append(errs, constants.ErrValidationFailed)
+
+	for _, fieldErr := range e {
+		errs = append(errs, fieldErr)
+	}
+
+	return errs
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+func (a *apiServer) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var product entities.Product
+
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, constants.ErrMalformedRequest)
+
+		return
+	}
+
+	created, err := a.prodAdminSvc.CreateProduct(ctx, product)
+	if err != nil {
+		if a.requestAborted(w, r, err) {
+			return
+		}
+
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	a.writeJSONResponse(w, http.StatusCreated, constants.SUCCESS, constants.ProductCreated, created)
+}
+
+func (a *apiServer) ReplaceProduct(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	prodID, err := strconv.ParseInt(r.PathValue("productId"), 10, 64)
+	if err != nil {
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, constants.ErrInvalidProductID)
+
+		return
+	}
+
+	var product entities.Product
+
+	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, constants.ErrMalformedRequest)
+
+		return
+	}
+
+	updated, err := a.prodAdminSvc.ReplaceProduct(ctx, prodID, product)
+	if err != nil {
+		if a.requestAborted(w, r, err) {
+			return
+		}
+
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.ProductUpdated, updated)
+}
+
+func (a *apiServer) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	prodID, err := strconv.ParseInt(r.PathValue("productId"), 10, 64)
+	if err != nil {
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, constants.ErrInvalidProductID)
+
+		return
+	}
+
+	var patch entities.ProductPatch
+
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, constants.ErrMalformedRequest)
+
+		return
+	}
+
+	updated, err := a.prodAdminSvc.PatchProduct(ctx, prodID, patch)
+	if err != nil {
+		if a.requestAborted(w, r, err) {
+			return
+		}
+
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.ProductUpdated, updated)
+}
+
+func (a *apiServer) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	prodID, err := strconv.ParseInt(r.PathValue("productId"), 10, 64)
+	if err != nil {
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, constants.ErrInvalidProductID)
+
+		return
+	}
+
+	if err := a.prodAdminSvc.DeleteProduct(ctx, prodID); err != nil {
+		if a.requestAborted(w, r, err) {
+			return
+		}
+
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.ProductDeleted, nil)
+}
@@ -2,25 +2,36 @@
 package server
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/sunimalherath/orderfoodonline/internal/app/utils"
 	"github.com/sunimalherath/orderfoodonline/internal/core/adapters"
 	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
 	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
 )
 
 type apiServer struct {
-	prodSvc  adapters.ProductService
-	orderSvc adapters.OrderService
-	apiKey   string
-	logger   *slog.Logger
+	prodSvc          adapters.ProductService
+	orderSvc         adapters.OrderService
+	prodAdminSvc     adapters.ProductAdminService
+	categorySvc      adapters.CategoryService
+	idempotencyStore adapters.IdempotencyStore
+	rateLimiter      adapters.RateLimiter
+	rateLimits       map[string]entities.RateLimit
+	metrics          adapters.Metrics
+	tracer           adapters.Tracer
+	keyStore         adapters.APIKeyStore
+	publicCatalog    bool
+	corsPolicy       entities.CORSPolicy
+	requestTimeout   time.Duration
+	routeTimeouts    map[string]time.Duration
+	logger           *slog.Logger
 }
 
 type APIServerOptions func(*apiServer)
@@ -31,11 +42,84 @@ func WithLogger(logger *slog.Logger) APIServerOptions {
 	}
 }
 
+// WithIdempotencyStore: enables Idempotency-Key support for placing orders.
+func WithIdempotencyStore(store adapters.IdempotencyStore) APIServerOptions {
+	return func(a *apiServer) {
+		a.idempotencyStore = store
+	}
+}
+
+// WithProductAdminService: enables the /admin/product endpoints.
+func WithProductAdminService(prodAdminSvc adapters.ProductAdminService) APIServerOptions {
+	return func(a *apiServer) {
+		a.prodAdminSvc = prodAdminSvc
+	}
+}
+
+// WithCategoryService: enables the /category endpoints.
+func WithCategoryService(categorySvc adapters.CategoryService) APIServerOptions {
+	return func(a *apiServer) {
+		a.categorySvc = categorySvc
+	}
+}
+
+// WithAPIKeyStore: the keys requests authenticate with. Without a store every protected request is rejected.
+func WithAPIKeyStore(keyStore adapters.APIKeyStore) APIServerOptions {
+	return func(a *apiServer) {
+		a.keyStore = keyStore
+	}
+}
+
+// WithRateLimiter: limits the requests per client by route pattern, constants.AnyRoute for the other routes.
+func WithRateLimiter(rateLimiter adapters.RateLimiter, rateLimits map[string]entities.RateLimit) APIServerOptions {
+	return func(a *apiServer) {
+		a.rateLimiter = rateLimiter
+		a.rateLimits = rateLimits
+	}
+}
+
+// WithMetrics: records the requests served and enables the /metrics endpoint.
+func WithMetrics(metrics adapters.Metrics) APIServerOptions {
+	return func(a *apiServer) {
+		a.metrics = metrics
+	}
+}
+
+// WithTracer: serves every request in a span, continuing the trace of the traceparent header.
+func WithTracer(tracer adapters.Tracer) APIServerOptions {
+	return func(a *apiServer) {
+		a.tracer = tracer
+	}
+}
+
+// WithCORSPolicy: the cross-origin requests browsers may make. The default policy allows no origin.
+func WithCORSPolicy(policy entities.CORSPolicy) APIServerOptions {
+	return func(a *apiServer) {
+		a.corsPolicy = policy
+	}
+}
+
+// WithPublicCatalog: whether the product and category endpoints can be called without an API key.
+func WithPublicCatalog(public bool) APIServerOptions {
+	return func(a *apiServer) {
+		a.publicCatalog = public
+	}
+}
+
+// WithRequestTimeouts: the deadline of every request, overridden per route pattern by routeTimeouts.
+func WithRequestTimeouts(requestTimeout time.Duration, routeTimeouts map[string]time.Duration) APIServerOptions {
+	return func(a *apiServer) {
+		a.requestTimeout = requestTimeout
+		a.routeTimeouts = routeTimeouts
+	}
+}
+
 func NewAPIServer(prodSvc adapters.ProductService, orderSvc adapters.OrderService, opts ...APIServerOptions) adapters.APIServer {
 	apiServer := &apiServer{
-		prodSvc:  prodSvc,
-		orderSvc: orderSvc,
-		apiKey:   utils.GetEnvVar(constants.APIKey, "apitest"),
+		prodSvc:        prodSvc,
+		orderSvc:       orderSvc,
+		publicCatalog:  true,
+		requestTimeout: constants.DefaultRequestTimeout,
 	}
 
 	for _, opt := range opts {
@@ -52,63 +136,111 @@ func NewAPIServer(prodSvc adapters.ProductService, orderSvc adapters.OrderServic
 func (a *apiServer) RegisterRoutes() http.Handler {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("GET /health", a.HealthCheck)
-	mux.HandleFunc("GET /product", a.ListProducts)
-	mux.HandleFunc("GET /product/{productId}", a.FindProductByID)
-	mux.HandleFunc("POST /order", a.PlaceAnOrder)
+	a.handleFunc(mux, "GET /health", publicRoute, a.HealthCheck)
+	a.handleFunc(mux, "GET /product", a.catalogPolicy(), a.ListProducts)
+	a.handleFunc(mux, "GET /product/{productId}", a.catalogPolicy(), a.FindProductByID)
+	a.handle(mux, "POST /order", requireScope(entities.ScopeOrdersWrite), a.idempotencyMiddleware(http.HandlerFunc(a.PlaceAnOrder)))
+	a.handleFunc(mux, "GET /order", requireScope(entities.ScopeOrdersRead), a.ListOrders)
+	a.handleFunc(mux, "GET /order/{orderId}", requireScope(entities.ScopeOrdersRead), a.GetOrderByID)
+	a.handleFunc(mux, "PATCH /order/{orderId}/status", requireScope(entities.ScopeOrdersWrite), a.UpdateOrderStatus)
+
+	if a.categorySvc != nil {
+		a.handleFunc(mux, "GET /category", a.catalogPolicy(), a.ListCategories)
+		a.handleFunc(mux, "GET /category/{categoryId}/product", a.catalogPolicy(), a.ListCategoryProducts)
+	}
+
+	if a.metrics != nil {
+		a.handleFunc(mux, "GET /metrics", publicRoute, a.Metrics)
+	}
+
+	if a.prodAdminSvc != nil {
+		a.handleFunc(mux, "POST /admin/product", requireScope(entities.ScopeAdmin), a.CreateProduct)
+		a.handleFunc(mux, "PUT /admin/product/{productId}", requireScope(entities.ScopeAdmin), a.ReplaceProduct)
+		a.handleFunc(mux, "PATCH /admin/product/{productId}", requireScope(entities.ScopeAdmin), a.PatchProduct)
+		a.handleFunc(mux, "DELETE /admin/product/{productId}", requireScope(entities.ScopeAdmin), a.DeleteProduct)
+	}
 
-	return a.configureCorsMiddleware(a.authAPIkeyMiddleware(mux))
+	return a.requestLogMiddleware(a.metricsMiddleware(a.corsMiddleware(mux)))
 }
 
 func (a *apiServer) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	a.logger.Info(constants.CheckHealth)
+	a.loggerFor(r).Info(constants.CheckHealth)
 	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.GoodHealth, nil)
 }
 
 func (a *apiServer) ListProducts(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), constants.ActiveDuration)
-	defer cancel()
+	ctx := r.Context()
 
-	products, err := a.prodSvc.ListProducts(ctx)
+	query, err := parseProductQuery(r)
 	if err != nil {
-		a.logger.Error(err.Error())
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	page, err := a.prodSvc.ListProducts(ctx, query)
+	if err != nil {
+		if a.requestAborted(w, r, err) {
+			return
+		}
 
-		a.writeJSONResponse(w, http.StatusInternalServerError, constants.FAILURE, constants.RetrievalFailed, nil)
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
 
 		return
+	}
 
+	meta := &entities.PageMeta{
+		Total:  page.Total,
+		Offset: page.Offset,
+		Limit:  page.Limit,
 	}
 
-	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.ProductsRcvd, products)
+	if next := page.Offset + len(page.Products); page.Limit > 0 && next < page.Total {
+		meta.Next = nextPageLink(r, next, page.Limit)
+	}
+
+	a.writeResponse(w, entities.APIResponse{
+		Code:    http.StatusOK,
+		Message: constants.ProductsRcvd,
+		Type:    constants.SUCCESS,
+		Data:    page.Products,
+		Meta:    meta,
+	})
 }
 
 func (a *apiServer) FindProductByID(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), constants.ActiveDuration)
-	defer cancel()
+	ctx := r.Context()
 
 	prodID, err := strconv.Atoi(r.PathValue("productId"))
 	if err != nil {
-		a.logger.Error(err.Error())
+		a.loggerFor(r).Error(err.Error())
 
-		a.writeJSONResponse(w, http.StatusBadRequest, constants.FAILURE, constants.InvalidProdID, nil)
+		a.writeError(w, r, constants.ErrInvalidProductID)
 
 		return
 	}
 
 	product, err := a.prodSvc.FindProductByID(ctx, int64(prodID))
 	if err != nil {
-		a.logger.Error(err.Error())
+		if a.requestAborted(w, r, err) {
+			return
+		}
+
+		a.loggerFor(r).Error(err.Error())
 
-		a.writeJSONResponse(w, http.StatusNotFound, constants.FAILURE, constants.ProdNotFound, nil)
+		a.writeError(w, r, err)
 
 		return
 	}
 
 	if product == nil {
-		a.logger.Warn("product not found for product Id", slog.Int("productId", prodID))
+		a.loggerFor(r).Warn("product not found for product Id", slog.Int("productId", prodID))
 
-		msg := fmt.Sprintf("product not for product ID: %d", prodID)
-		a.writeJSONResponse(w, http.StatusInternalServerError, constants.FAILURE, msg, nil)
+		a.writeError(w, r, fmt.Errorf("no product returned for product ID: %d", prodID))
 
 		return
 	}
@@ -118,34 +250,75 @@ func (a *apiServer) FindProductByID(w http.ResponseWriter, r *http.Request) {
 	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, msg, product)
 }
 
+func (a *apiServer) ListCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	categories, err := a.categorySvc.ListCategories(ctx)
+	if err != nil {
+		if a.requestAborted(w, r, err) {
+			return
+		}
+
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.CategoriesRcvd, categories)
+}
+
+func (a *apiServer) ListCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	products, err := a.categorySvc.ListCategoryProducts(ctx, r.PathValue("categoryId"))
+	if err != nil {
+		if a.requestAborted(w, r, err) {
+			return
+		}
+
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.ProductsRcvd, products)
+}
+
 func (a *apiServer) PlaceAnOrder(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), constants.ActiveDuration)
-	defer cancel()
+	ctx := r.Context()
 
 	var orderReq entities.OrderReq
 
 	err := json.NewDecoder(r.Body).Decode(&orderReq)
 	if err != nil {
-		a.logger.Error(err.Error())
+		a.loggerFor(r).Error(err.Error())
 
-		a.writeJSONResponse(w, http.StatusBadRequest, constants.FAILURE, constants.InvalidRequest, nil)
+		a.writeError(w, r, constants.ErrMalformedRequest)
 
 		return
 	}
 
 	if err := orderReq.Validate(); err != nil {
-		a.logger.Error(err.Error())
+		a.loggerFor(r).Error(err.Error())
 
-		a.writeJSONResponse(w, http.StatusUnprocessableEntity, constants.FAILURE, constants.ValidationFailed, nil)
+		a.writeError(w, r, err)
 
 		return
 	}
 
 	order, err := a.orderSvc.PlaceAnOrder(ctx, orderReq)
 	if err != nil {
-		a.logger.Error(err.Error())
+		if a.requestAborted(w, r, err) {
+			return
+		}
 
-		a.writeJSONResponse(w, getStatusCode(err), constants.FAILURE, err.Error(), nil)
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
 
 		return
 	}
@@ -153,47 +326,87 @@ func (a *apiServer) PlaceAnOrder(w http.ResponseWriter, r *http.Request) {
 	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.OrderPlaced, order)
 }
 
-func (a *apiServer) configureCorsMiddleware(h http.Handler) http.Handler {
-	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+func (a *apiServer) GetOrderByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-		h.ServeHTTP(w, r)
-	})
+	order, err := a.orderSvc.GetOrderByID(ctx, r.PathValue("orderId"))
+	if err != nil {
+		if a.requestAborted(w, r, err) {
+			return
+		}
 
-	return hf
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.OrderRcvd, order)
 }
 
-func (a *apiServer) authAPIkeyMiddleware(h http.Handler) http.Handler {
-	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		key := r.Header.Get("api_key")
+func (a *apiServer) ListOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-		if key == "" {
-			a.logger.Error(constants.MissingAPIkey)
-			a.writeJSONResponse(w, http.StatusUnauthorized, constants.FAILURE, constants.MissingAPIkey, nil)
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		a.loggerFor(r).Error(err.Error())
 
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	page, err := a.orderSvc.ListOrders(ctx, offset, limit)
+	if err != nil {
+		if a.requestAborted(w, r, err) {
 			return
 		}
 
-		if key != a.apiKey {
-			a.logger.Error(constants.InvalidAPIkey)
-			a.writeJSONResponse(w, http.StatusBadRequest, constants.FAILURE, constants.InvalidAPIkey, nil)
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
+
+		return
+	}
+
+	if next := page.Offset + len(page.Orders); next < page.Total {
+		page.Next = nextPageLink(r, next, page.Limit)
+	}
+
+	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.OrdersRcvd, page)
+}
 
+func (a *apiServer) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var statusReq entities.OrderStatusReq
+
+	if err := json.NewDecoder(r.Body).Decode(&statusReq); err != nil {
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, constants.ErrMalformedRequest)
+
+		return
+	}
+
+	order, err := a.orderSvc.UpdateOrderStatus(ctx, r.PathValue("orderId"), statusReq.Status)
+	if err != nil {
+		if a.requestAborted(w, r, err) {
 			return
 		}
 
-		h.ServeHTTP(w, r)
-	})
+		a.loggerFor(r).Error(err.Error())
+
+		a.writeError(w, r, err)
 
-	return hf
+		return
+	}
+
+	a.writeJSONResponse(w, http.StatusOK, constants.SUCCESS, constants.StatusUpdated, order)
 }
 
 func (a *apiServer) writeJSONResponse(w http.ResponseWriter, status int, respType, message string, data any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-
 	res := entities.APIResponse{
 		Code:    status,
 		Message: message,
@@ -204,6 +417,13 @@ func (a *apiServer) writeJSONResponse(w http.ResponseWriter, status int, respTyp
 		res.Data = data
 	}
 
+	a.writeResponse(w, res)
+}
+
+func (a *apiServer) writeResponse(w http.ResponseWriter, res entities.APIResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.Code)
+
 	err := json.NewEncoder(w).Encode(res)
 	if err != nil {
 		a.logger.Error(err.Error())
@@ -211,15 +431,82 @@ func (a *apiServer) writeJSONResponse(w http.ResponseWriter, status int, respTyp
 	}
 }
 
-func getStatusCode(err error) int {
-	switch err {
-	case constants.ErrInvalidPromoCodeLength:
-		return http.StatusUnprocessableEntity
-	case constants.ErrInvalidPromoCode:
-		return http.StatusBadRequest
-	case constants.ErrProductNotFound:
-		return http.StatusBadRequest
-	default:
-		return http.StatusInternalServerError
+// parsePagination reads the optional offset and limit query parameters.
+func parsePagination(r *http.Request) (int, int, error) {
+	offset, limit := 0, 0
+
+	var err error
+
+	if value := r.URL.Query().Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("%w: %s", constants.ErrInvalidPagination, err.Error())
+		}
+	}
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			return 0, 0, fmt.Errorf("%w: %s", constants.ErrInvalidPagination, err.Error())
+		}
+	}
+
+	if offset < 0 || limit < 0 {
+		return 0, 0, constants.ErrInvalidPagination
+	}
+
+	return offset, limit, nil
+}
+
+// parseProductQuery reads the product listing query parameters:
+// category, minPrice, maxPrice, q, sort (id, name or price, prefixed with - for descending), offset and limit.
+func parseProductQuery(r *http.Request) (entities.ProductQuery, error) {
+	params := r.URL.Query()
+
+	query := entities.ProductQuery{
+		Category: params.Get("category"),
+		Search:   params.Get("q"),
 	}
+
+	var err error
+
+	if query.MinPrice, err = parseOptionalPrice(params.Get("minPrice")); err != nil {
+		return query, err
+	}
+
+	if query.MaxPrice, err = parseOptionalPrice(params.Get("maxPrice")); err != nil {
+		return query, err
+	}
+
+	if sort := params.Get("sort"); sort != "" {
+		query.Descending = strings.HasPrefix(sort, "-")
+		query.Sort = entities.ProductSort(strings.TrimPrefix(sort, "-"))
+	}
+
+	query.Offset, query.Limit, err = parsePagination(r)
+	if err != nil {
+		return query, err
+	}
+
+	return query, nil
+}
+
+func parseOptionalPrice(value string) (*entities.Money, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := entities.ParseMoney(value, "")
+	if err != nil {
+		return nil, constants.ErrInvalidPriceFilter
+	}
+
+	return &price, nil
+}
+
+// nextPageLink: the request URL with the offset and limit of the following page.
+func nextPageLink(r *http.Request, offset, limit int) string {
+	query := r.URL.Query()
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+
+	return fmt.Sprintf("%s?%s", r.URL.Path, query.Encode())
 }
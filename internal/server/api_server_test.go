@@ -5,24 +5,31 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/sunimalherath/orderfoodonline/internal/app/repositories"
+	"github.com/sunimalherath/orderfoodonline/internal/app/tracing"
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
 	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
 )
 
 type mockProductService struct {
-	listProductsFunc    func(ctx context.Context) ([]entities.Product, error)
+	listProductsFunc    func(ctx context.Context, query entities.ProductQuery) (*entities.ProductPage, error)
 	findProductByIDFunc func(ctx context.Context, productID int64) (*entities.Product, error)
 }
 
-func (m *mockProductService) ListProducts(ctx context.Context) ([]entities.Product, error) {
+func (m *mockProductService) ListProducts(ctx context.Context, query entities.ProductQuery) (*entities.ProductPage, error) {
 	if m.listProductsFunc != nil {
-		return m.listProductsFunc(ctx)
+		return m.listProductsFunc(ctx, query)
 	}
 
 	return nil, nil
@@ -38,6 +45,9 @@ func (m *mockProductService) FindProductByID(ctx context.Context, productID int6
 
 type mockOrderService struct {
 	placeAnOrderFunc func(ctx context.Context, orderReq entities.OrderReq) (*entities.Order, error)
+	getOrderByIDFunc func(ctx context.Context, orderID string) (*entities.Order, error)
+	listOrdersFunc   func(ctx context.Context, offset, limit int) (*entities.OrderPage, error)
+	updateStatusFunc func(ctx context.Context, orderID string, status entities.OrderStatus) (*entities.Order, error)
 }
 
 func (m *mockOrderService) PlaceAnOrder(ctx context.Context, orderReq entities.OrderReq) (*entities.Order, error) {
@@ -48,13 +58,46 @@ func (m *mockOrderService) PlaceAnOrder(ctx context.Context, orderReq entities.O
 	return nil, nil
 }
 
+func (m *mockOrderService) GetOrderByID(ctx context.Context, orderID string) (*entities.Order, error) {
+	if m.getOrderByIDFunc != nil {
+		return m.getOrderByIDFunc(ctx, orderID)
+	}
+
+	return nil, nil
+}
+
+func (m *mockOrderService) UpdateOrderStatus(ctx context.Context, orderID string, status entities.OrderStatus) (*entities.Order, error) {
+	if m.updateStatusFunc != nil {
+		return m.updateStatusFunc(ctx, orderID, status)
+	}
+
+	return nil, nil
+}
+
+func (m *mockOrderService) ListOrders(ctx context.Context, offset, limit int) (*entities.OrderPage, error) {
+	if m.listOrdersFunc != nil {
+		return m.listOrdersFunc(ctx, offset, limit)
+	}
+
+	return nil, nil
+}
+
 func newTestServer(prodSvc *mockProductService, orderSvc *mockOrderService) *apiServer {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
+	keyStore := repositories.NewAPIKeyStore([]entities.APIKey{
+		{
+			Name:   "test",
+			Hash:   entities.HashAPIKey("test-api-key"),
+			Scopes: []entities.Scope{entities.ScopeProductsRead, entities.ScopeOrdersRead, entities.ScopeOrdersWrite},
+		},
+		{Name: "test-admin", Hash: entities.HashAPIKey("test-admin-key"), Scopes: []entities.Scope{entities.ScopeAdmin}},
+	})
+
 	return &apiServer{
 		prodSvc:  prodSvc,
 		orderSvc: orderSvc,
-		apiKey:   "test-api-key",
+		keyStore: keyStore,
 		logger:   logger,
 	}
 }
@@ -62,10 +105,13 @@ func newTestServer(prodSvc *mockProductService, orderSvc *mockOrderService) *api
 func TestListProducts_Success(t *testing.T) {
 	expectedStatus := http.StatusOK
 
-	mockFunc := func(ctx context.Context) ([]entities.Product, error) {
-		return []entities.Product{
-			{ID: "1", Name: "Product 1", Category: "Category A", Price: 10.99},
-			{ID: "2", Name: "Product 2", Category: "Category B", Price: 20.99},
+	mockFunc := func(ctx context.Context, query entities.ProductQuery) (*entities.ProductPage, error) {
+		return &entities.ProductPage{
+			Products: []entities.Product{
+				{ID: "1", Name: "Product 1", Category: "Category A", Price: entities.NewMoney(1099, "USD")},
+				{ID: "2", Name: "Product 2", Category: "Category B", Price: entities.NewMoney(2099, "USD")},
+			},
+			Total: 2,
 		}, nil
 	}
 
@@ -88,7 +134,7 @@ func TestListProducts_Success(t *testing.T) {
 func TestListProducts_Failure(t *testing.T) {
 	expectedStatus := http.StatusInternalServerError
 
-	mockFunc := func(ctx context.Context) ([]entities.Product, error) {
+	mockFunc := func(ctx context.Context, query entities.ProductQuery) (*entities.ProductPage, error) {
 		return nil, errors.New("random test error")
 	}
 
@@ -108,6 +154,55 @@ func TestListProducts_Failure(t *testing.T) {
 	}
 }
 
+func TestListProducts_QueryParams(t *testing.T) {
+	var received entities.ProductQuery
+
+	mockFunc := func(ctx context.Context, query entities.ProductQuery) (*entities.ProductPage, error) {
+		received = query
+
+		return &entities.ProductPage{Products: []entities.Product{{ID: "3"}}, Total: 5, Offset: query.Offset, Limit: query.Limit}, nil
+	}
+
+	server := newTestServer(&mockProductService{listProductsFunc: mockFunc}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/product?category=Waffle&minPrice=5&q=berry&sort=-price&offset=2&limit=1", nil)
+	w := httptest.NewRecorder()
+
+	server.ListProducts(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if received.Category != "Waffle" || received.Search != "berry" || received.Sort != entities.SortByPrice ||
+		!received.Descending || received.Offset != 2 || received.Limit != 1 || received.MinPrice == nil || received.MaxPrice != nil {
+		t.Errorf("unexpected product query: %+v", received)
+	}
+
+	var res entities.APIResponse
+
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if res.Meta == nil || res.Meta.Next != "/product?category=Waffle&limit=1&minPrice=5&offset=3&q=berry&sort=-price" {
+		t.Errorf("unexpected page meta: %+v", res.Meta)
+	}
+}
+
+func TestListProducts_InvalidQuery(t *testing.T) {
+	server := newTestServer(&mockProductService{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/product?maxPrice=cheap", nil)
+	w := httptest.NewRecorder()
+
+	server.ListProducts(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func TestFindProductByID_Success(t *testing.T) {
 	expectedStatus := http.StatusOK
 	productID := "1"
@@ -117,7 +212,7 @@ func TestFindProductByID_Success(t *testing.T) {
 			ID:       "1",
 			Name:     "Product 1",
 			Category: "Category A",
-			Price:    10.99,
+			Price:    entities.NewMoney(1099, "USD"),
 		}, nil
 	}
 
@@ -246,3 +341,796 @@ func TestPlaceAnOrder_Failure(t *testing.T) {
 		t.Errorf("expected status %d, got %d", expectedStatus, w.Code)
 	}
 }
+
+func TestGetOrderByID_Success(t *testing.T) {
+	expectedStatus := http.StatusOK
+	orderID := uuid.New().String()
+
+	mockFunc := func(ctx context.Context, id string) (*entities.Order, error) {
+		return &entities.Order{ID: id}, nil
+	}
+
+	server := newTestServer(nil, &mockOrderService{getOrderByIDFunc: mockFunc})
+
+	req := httptest.NewRequest(http.MethodGet, "/order/"+orderID, nil)
+	req.SetPathValue("orderId", orderID)
+	w := httptest.NewRecorder()
+
+	server.GetOrderByID(w, req)
+
+	if w.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, w.Code)
+	}
+}
+
+func TestGetOrderByID_NotFound(t *testing.T) {
+	expectedStatus := http.StatusNotFound
+
+	mockFunc := func(ctx context.Context, id string) (*entities.Order, error) {
+		return nil, constants.ErrOrderNotFound
+	}
+
+	server := newTestServer(nil, &mockOrderService{getOrderByIDFunc: mockFunc})
+
+	req := httptest.NewRequest(http.MethodGet, "/order/unknown", nil)
+	req.SetPathValue("orderId", "unknown")
+	w := httptest.NewRecorder()
+
+	server.GetOrderByID(w, req)
+
+	if w.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, w.Code)
+	}
+}
+
+func TestListOrders_NextLink(t *testing.T) {
+	expectedNext := "/order?limit=2&offset=2"
+
+	mockFunc := func(ctx context.Context, offset, limit int) (*entities.OrderPage, error) {
+		return &entities.OrderPage{
+			Orders: []entities.Order{{ID: "b"}, {ID: "a"}},
+			Total:  3,
+			Offset: offset,
+			Limit:  limit,
+		}, nil
+	}
+
+	server := newTestServer(nil, &mockOrderService{listOrdersFunc: mockFunc})
+
+	req := httptest.NewRequest(http.MethodGet, "/order?limit=2", nil)
+	w := httptest.NewRecorder()
+
+	server.ListOrders(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var res struct {
+		Data entities.OrderPage `json:"data"`
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if res.Data.Next != expectedNext {
+		t.Errorf("expected next %q, got %q", expectedNext, res.Data.Next)
+	}
+}
+
+func TestListOrders_InvalidPagination(t *testing.T) {
+	expectedStatus := http.StatusBadRequest
+
+	server := newTestServer(nil, &mockOrderService{})
+
+	req := httptest.NewRequest(http.MethodGet, "/order?offset=-1", nil)
+	w := httptest.NewRecorder()
+
+	server.ListOrders(w, req)
+
+	if w.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, w.Code)
+	}
+}
+
+func TestUpdateOrderStatus_IllegalTransition(t *testing.T) {
+	expectedStatus := http.StatusConflict
+
+	mockFunc := func(ctx context.Context, orderID string, status entities.OrderStatus) (*entities.Order, error) {
+		order := &entities.Order{ID: orderID}
+		order.Place(time.Now())
+
+		return nil, order.TransitionTo(status, time.Now())
+	}
+
+	server := newTestServer(nil, &mockOrderService{updateStatusFunc: mockFunc})
+
+	req := httptest.NewRequest(http.MethodPatch, "/order/abc/status", bytes.NewReader([]byte(`{"status":"completed"}`)))
+	req.SetPathValue("orderId", "abc")
+	w := httptest.NewRecorder()
+
+	server.UpdateOrderStatus(w, req)
+
+	if w.Code != expectedStatus {
+		t.Errorf("expected status %d, got %d", expectedStatus, w.Code)
+	}
+}
+
+func TestPlaceAnOrder_IdempotencyKey(t *testing.T) {
+	calls := 0
+
+	mockFunc := func(ctx context.Context, orderReq entities.OrderReq) (*entities.Order, error) {
+		calls++
+
+		return &entities.Order{ID: uuid.New().String(), Items: orderReq.Items}, nil
+	}
+
+	server := newTestServer(nil, &mockOrderService{placeAnOrderFunc: mockFunc})
+	server.idempotencyStore = repositories.NewIdempotencyStore(time.Hour)
+
+	handler := server.idempotencyMiddleware(http.HandlerFunc(server.PlaceAnOrder))
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader([]byte(body)))
+		req.Header.Set(idempotencyKeyHeader, "retry-1")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	first := send(`{"items":[{"productId":"1","quantity":2}]}`)
+	retry := send(`{"items":[{"productId":"1","quantity":2}]}`)
+
+	if first.Code != http.StatusOK || retry.Code != http.StatusOK {
+		t.Fatalf("expected status %d for both requests, got %d and %d", http.StatusOK, first.Code, retry.Code)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the order to be placed once, got %d", calls)
+	}
+
+	if first.Body.String() != retry.Body.String() || retry.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("expected the retry to replay the original response")
+	}
+
+	if conflict := send(`{"items":[{"productId":"2","quantity":1}]}`); conflict.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a different body, got %d", http.StatusConflict, conflict.Code)
+	}
+}
+
+func TestIdempotencyMiddleware_ReplaysHeaders(t *testing.T) {
+	server := newTestServer(nil, &mockOrderService{})
+	server.idempotencyStore = repositories.NewIdempotencyStore(time.Hour)
+
+	calls := 0
+
+	handler := server.idempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		w.Header().Set("Location", "/order/"+strconv.Itoa(calls))
+		w.Header().Add("Link", "</product/1>; rel=related")
+		w.Header().Add("Link", "</product/4>; rel=related")
+		w.Header().Set("Connection", "X-Hop")
+		w.Header().Set("X-Hop", "1")
+		w.Header().Set("Keep-Alive", "timeout=5")
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/order", strings.NewReader(`{}`))
+		req.Header.Set(idempotencyKeyHeader, "headers-1")
+		w := httptest.NewRecorder()
+		w.Header().Set(requestIDHeader, requestID)
+
+		handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	send("first")
+	retry := send("retry")
+
+	if calls != 1 || retry.Code != http.StatusCreated || retry.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Fatalf("expected a replayed %d, got %d after %d calls", http.StatusCreated, retry.Code, calls)
+	}
+
+	if location := retry.Header().Get("Location"); location != "/order/1" {
+		t.Errorf("expected the Location of the first response, got %q", location)
+	}
+
+	if links := retry.Header().Values("Link"); len(links) != 2 {
+		t.Errorf("expected both Link headers, got %v", links)
+	}
+
+	if requestID := retry.Header().Get(requestIDHeader); requestID != "retry" {
+		t.Errorf("expected the request id of the retry, got %q", requestID)
+	}
+
+	for _, name := range []string{"Connection", "X-Hop", "Keep-Alive"} {
+		if value := retry.Header().Get(name); value != "" {
+			t.Errorf("expected the hop-by-hop header %s not to be replayed, got %q", name, value)
+		}
+	}
+}
+
+func TestPlaceAnOrder_IdempotencyKeyBodyTooLarge(t *testing.T) {
+	calls := 0
+
+	mockFunc := func(ctx context.Context, orderReq entities.OrderReq) (*entities.Order, error) {
+		calls++
+
+		return &entities.Order{ID: uuid.New().String(), Items: orderReq.Items}, nil
+	}
+
+	server := newTestServer(nil, &mockOrderService{placeAnOrderFunc: mockFunc})
+	server.idempotencyStore = repositories.NewIdempotencyStore(time.Hour)
+
+	handler := server.idempotencyMiddleware(http.HandlerFunc(server.PlaceAnOrder))
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/order", strings.NewReader(body))
+		req.Header.Set(idempotencyKeyHeader, "large-1")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	body := `{"items":[{"productId":"1","quantity":2}]}`
+
+	if large := send(body + strings.Repeat(" ", maxIdempotentRequestBytes)); large.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d, got %d", http.StatusRequestEntityTooLarge, large.Code)
+	}
+
+	if retry := send(body); retry.Code != http.StatusOK || retry.Header().Get(idempotentReplayedHeader) != "" {
+		t.Errorf("expected the rejected request not to be recorded, got status %d", retry.Code)
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the order to be placed once, got %d", calls)
+	}
+}
+
+type mockProductAdminService struct {
+	createProductFunc func(ctx context.Context, product entities.Product) (*entities.Product, error)
+}
+
+func (m *mockProductAdminService) CreateProduct(ctx context.Context, product entities.Product) (*entities.Product, error) {
+	if m.createProductFunc != nil {
+		return m.createProductFunc(ctx, product)
+	}
+
+	return nil, nil
+}
+
+func (m *mockProductAdminService) ReplaceProduct(ctx context.Context, productID int64, product entities.Product) (*entities.Product, error) {
+	return nil, nil
+}
+
+func (m *mockProductAdminService) PatchProduct(ctx context.Context, productID int64, patch entities.ProductPatch) (*entities.Product, error) {
+	return nil, nil
+}
+
+func (m *mockProductAdminService) DeleteProduct(ctx context.Context, productID int64) error {
+	return nil
+}
+
+func TestCreateProduct_AdminKey(t *testing.T) {
+	mockFunc := func(ctx context.Context, product entities.Product) (*entities.Product, error) {
+		product.ID = "10"
+
+		return &product, nil
+	}
+
+	server := newTestServer(nil, nil)
+	server.prodAdminSvc = &mockProductAdminService{createProductFunc: mockFunc}
+
+	handler := server.RegisterRoutes()
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		{name: "admin key", headers: map[string]string{adminKeyHeader: "test-admin-key"}, expectedStatus: http.StatusCreated},
+		{name: "admin key in api_key", headers: map[string]string{apiKeyHeader: "test-admin-key"}, expectedStatus: http.StatusCreated},
+		{name: "api key without the admin scope", headers: map[string]string{apiKeyHeader: "test-api-key"}, expectedStatus: http.StatusForbidden},
+		{name: "wrong admin key", headers: map[string]string{adminKeyHeader: "wrong-key"}, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		body := []byte(`{"name":"Lemon Tart","category":"Tart","price":4.5}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/product", bytes.NewReader(body))
+		for key, value := range tc.headers {
+			req.Header.Set(key, value)
+		}
+
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.expectedStatus, w.Code)
+		}
+	}
+}
+
+func TestPlaceAnOrder_RequestContext(t *testing.T) {
+	mockOrderSvc := &mockOrderService{
+		placeAnOrderFunc: func(ctx context.Context, orderReq entities.OrderReq) (*entities.Order, error) {
+			<-ctx.Done()
+
+			return nil, ctx.Err()
+		},
+	}
+
+	server := newTestServer(nil, mockOrderSvc)
+	server.requestTimeout = time.Minute
+	server.routeTimeouts = map[string]time.Duration{"POST /order": 10 * time.Millisecond}
+
+	handler := server.RegisterRoutes()
+	body := `{"items": [{"productId": "1", "quantity": 1}]}`
+
+	req := httptest.NewRequest(http.MethodPost, "/order", bytes.NewReader([]byte(body)))
+	req.Header.Set("api_key", "test-api-key")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected status %d after the route deadline, got %d", http.StatusGatewayTimeout, w.Code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req = httptest.NewRequestWithContext(ctx, http.MethodPost, "/order", bytes.NewReader([]byte(body)))
+	req.Header.Set("api_key", "test-api-key")
+	w = httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != StatusClientClosedRequest {
+		t.Errorf("expected status %d for a cancelled request, got %d", StatusClientClosedRequest, w.Code)
+	}
+}
+
+func TestRegisterRoutes_AuthPolicy(t *testing.T) {
+	mockProdSvc := &mockProductService{
+		listProductsFunc: func(ctx context.Context, query entities.ProductQuery) (*entities.ProductPage, error) {
+			return &entities.ProductPage{Products: []entities.Product{}}, nil
+		},
+	}
+
+	mockOrderSvc := &mockOrderService{
+		listOrdersFunc: func(ctx context.Context, offset, limit int) (*entities.OrderPage, error) {
+			return &entities.OrderPage{Orders: []entities.Order{}}, nil
+		},
+	}
+
+	server := newTestServer(mockProdSvc, mockOrderSvc)
+	server.publicCatalog = true
+
+	handler := server.RegisterRoutes()
+
+	tests := []struct {
+		path           string
+		apiKey         string
+		expectedStatus int
+	}{
+		{path: "/health", expectedStatus: http.StatusOK},
+		{path: "/product", expectedStatus: http.StatusOK},
+		{path: "/order", expectedStatus: http.StatusUnauthorized},
+		{path: "/order", apiKey: "wrong-key", expectedStatus: http.StatusUnauthorized},
+		{path: "/order", apiKey: "test-api-key", expectedStatus: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.apiKey != "" {
+			req.Header.Set(apiKeyHeader, tc.apiKey)
+		}
+
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedStatus {
+			t.Errorf("GET %s with key %q: expected status %d, got %d", tc.path, tc.apiKey, tc.expectedStatus, w.Code)
+		}
+	}
+}
+
+func TestRegisterRoutes_RateLimitsRejectedKeys(t *testing.T) {
+	server := newTestServer(&mockProductService{}, &mockOrderService{})
+	server.rateLimiter = repositories.NewRateLimiter()
+	server.rateLimits = map[string]entities.RateLimit{constants.AnyRoute: {Requests: 2, Per: time.Minute}}
+
+	handler := server.RegisterRoutes()
+
+	for i, expectedStatus := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/order", nil)
+		req.Header.Set(apiKeyHeader, fmt.Sprintf("guessed-key-%d", i))
+
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != expectedStatus {
+			t.Errorf("request %d: expected status %d, got %d", i+1, expectedStatus, w.Code)
+		}
+	}
+}
+
+func TestRegisterRoutes_RateLimitsPerAPIKey(t *testing.T) {
+	server := newTestServer(&mockProductService{}, &mockOrderService{
+		listOrdersFunc: func(ctx context.Context, offset, limit int) (*entities.OrderPage, error) {
+			return &entities.OrderPage{Orders: []entities.Order{}}, nil
+		},
+	})
+	server.rateLimiter = repositories.NewRateLimiter()
+	server.rateLimits = map[string]entities.RateLimit{constants.AnyRoute: {Requests: 1, Per: time.Minute}}
+
+	handler := server.RegisterRoutes()
+
+	tests := []struct {
+		apiKey         string
+		expectedStatus int
+	}{
+		{apiKey: "test-api-key", expectedStatus: http.StatusOK},
+		{apiKey: "test-api-key", expectedStatus: http.StatusTooManyRequests},
+		{apiKey: "test-admin-key", expectedStatus: http.StatusOK},
+	}
+
+	for i, tc := range tests {
+		// every request comes from the same address, as kiosks behind one NAT would.
+		req := httptest.NewRequest(http.MethodGet, "/order", nil)
+		req.Header.Set(apiKeyHeader, tc.apiKey)
+
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.expectedStatus {
+			t.Errorf("request %d with key %q: expected status %d, got %d", i+1, tc.apiKey, tc.expectedStatus, w.Code)
+		}
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	policy := entities.CORSPolicy{
+		AllowedOrigins: []string{"https://shop.example.com", "https://*.partner.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "api_key"},
+		ExposedHeaders: []string{"Retry-After"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name           string
+		policy         entities.CORSPolicy
+		method         string
+		origin         string
+		preflight      bool
+		expectedStatus int
+		expectedOrigin string
+	}{
+		{"no origin", policy, http.MethodGet, "", false, http.StatusOK, ""},
+		{"exact origin", policy, http.MethodGet, "https://shop.example.com", false, http.StatusOK, "https://shop.example.com"},
+		{"wildcard subdomain", policy, http.MethodGet, "https://eu.api.partner.com", false, http.StatusOK, "https://eu.api.partner.com"},
+		{"wildcard does not match the bare domain", policy, http.MethodGet, "https://partner.com", false, http.StatusOK, ""},
+		{"wildcard does not match another scheme", policy, http.MethodGet, "http://eu.partner.com", false, http.StatusOK, ""},
+		{"disallowed origin", policy, http.MethodGet, "https://evil.com", false, http.StatusOK, ""},
+		{"preflight", policy, http.MethodOptions, "https://shop.example.com", true, http.StatusNoContent, "https://shop.example.com"},
+		{"disallowed preflight", policy, http.MethodOptions, "https://evil.com", true, http.StatusForbidden, ""},
+		{"any origin", entities.CORSPolicy{AllowedOrigins: []string{"*"}}, http.MethodGet, "https://evil.com", false, http.StatusOK, "*"},
+		{
+			"credentials echo an exact origin",
+			entities.CORSPolicy{AllowedOrigins: []string{"https://shop.example.com"}, AllowCredentials: true},
+			http.MethodGet, "https://shop.example.com", false, http.StatusOK, "https://shop.example.com",
+		},
+		{
+			"credentials never echo an origin matched by any origin",
+			entities.CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			http.MethodGet, "https://evil.com", false, http.StatusOK, "",
+		},
+		{
+			"credentials never echo an origin matched by a wildcard",
+			entities.CORSPolicy{AllowedOrigins: []string{"https://*.partner.com"}, AllowCredentials: true},
+			http.MethodOptions, "https://eu.partner.com", true, http.StatusForbidden, "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(&mockProductService{}, &mockOrderService{})
+			server.corsPolicy = tt.policy
+
+			req := httptest.NewRequest(tt.method, "/product", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+
+			rr := httptest.NewRecorder()
+			server.corsMiddleware(next).ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != tt.expectedOrigin {
+				t.Errorf("expected allowed origin %q, got %q", tt.expectedOrigin, origin)
+			}
+
+			credentials := rr.Header().Get("Access-Control-Allow-Credentials")
+			if credentials != "" && rr.Header().Get("Access-Control-Allow-Origin") == "*" {
+				t.Error("credentials allowed together with the any origin")
+			}
+
+			if tt.preflight && tt.expectedStatus == http.StatusNoContent {
+				if methods := rr.Header().Get("Access-Control-Allow-Methods"); methods != "GET, POST" {
+					t.Errorf("expected allowed methods %q, got %q", "GET, POST", methods)
+				}
+
+				if headers := rr.Header().Get("Access-Control-Allow-Headers"); headers != "Content-Type, api_key" {
+					t.Errorf("expected allowed headers %q, got %q", "Content-Type, api_key", headers)
+				}
+
+				if maxAge := rr.Header().Get("Access-Control-Max-Age"); maxAge != "600" {
+					t.Errorf("expected max age 600, got %q", maxAge)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	validationErr := entities.FieldError{Field: "items[2].quantity", Err: constants.ErrProductQtyReqd}
+
+	tests := []struct {
+		name           string
+		err            error
+		accept         string
+		expectedStatus int
+		expectedType   string
+		expectedCode   string
+		expectedField  string
+	}{
+		{"validation error", validationErr, "", http.StatusUnprocessableEntity, problemContentType, "quantity_required", "items[2].quantity"},
+		{
+			"validation errors",
+			entities.ValidationErrors{validationErr, {Field: "couponCode", Err: constants.ErrInvalidPromoCodeLength}},
+			"", http.StatusUnprocessableEntity, problemContentType, "validation_failed", "items[2].quantity",
+		},
+		{"wrapped domain error", fmt.Errorf("%w: order 1", constants.ErrOrderNotFound), "*/*", http.StatusNotFound, problemContentType, "order_not_found", ""},
+		{"internal error", errors.New("open products.json: permission denied"), "", http.StatusInternalServerError, problemContentType, "internal_error", ""},
+		{"problem preferred", constants.ErrOrderNotFound, "application/problem+json, application/json;q=0.5", http.StatusNotFound, problemContentType, "order_not_found", ""},
+		{"legacy envelope", validationErr, "application/json", http.StatusUnprocessableEntity, "application/json", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(&mockProductService{}, &mockOrderService{})
+
+			req := httptest.NewRequest(http.MethodPost, "/order", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			server.writeError(rr, req, tt.err)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			if contentType := rr.Header().Get("Content-Type"); contentType != tt.expectedType {
+				t.Fatalf("expected content type %q, got %q", tt.expectedType, contentType)
+			}
+
+			if tt.expectedType != problemContentType {
+				var res entities.APIResponse
+				if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+
+				if res.Type != constants.FAILURE || res.Code != tt.expectedStatus {
+					t.Errorf("unexpected legacy response %+v", res)
+				}
+
+				return
+			}
+
+			var problem entities.Problem
+			if err := json.NewDecoder(rr.Body).Decode(&problem); err != nil {
+				t.Fatalf("failed to decode problem: %v", err)
+			}
+
+			if problem.Code != tt.expectedCode || problem.Status != tt.expectedStatus || problem.Instance != "/order" {
+				t.Errorf("unexpected problem %+v", problem)
+			}
+
+			if problem.Code == "internal_error" && problem.Detail != "" {
+				t.Errorf("internal error detail leaked: %q", problem.Detail)
+			}
+
+			if tt.expectedField != "" && (len(problem.Errors) == 0 || problem.Errors[0].Field != tt.expectedField) {
+				t.Errorf("expected field error for %s, got %+v", tt.expectedField, problem.Errors)
+			}
+		})
+	}
+}
+
+func TestRequestLogMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+
+	server := newTestServer(&mockProductService{}, &mockOrderService{
+		getOrderByIDFunc: func(ctx context.Context, orderID string) (*entities.Order, error) {
+			return &entities.Order{ID: orderID}, nil
+		},
+	})
+	server.logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	handler := server.RegisterRoutes()
+
+	tests := []struct {
+		name      string
+		requestID string
+		echoed    bool
+	}{
+		{"client request id", "req-123", true},
+		{"generated request id", "", false},
+		{"unsafe request id replaced", "bad id\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/order/42", nil)
+			req.Header.Set("api_key", "test-api-key")
+
+			if tt.requestID != "" {
+				req.Header.Set(requestIDHeader, tt.requestID)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			requestID := rr.Header().Get(requestIDHeader)
+			if tt.echoed && requestID != tt.requestID {
+				t.Errorf("expected request id %q, got %q", tt.requestID, requestID)
+			}
+
+			if !tt.echoed && (requestID == "" || requestID == tt.requestID) {
+				t.Errorf("expected a generated request id, got %q", requestID)
+			}
+
+			var line struct {
+				Msg       string `json:"msg"`
+				RequestID string `json:"requestId"`
+				Method    string `json:"method"`
+				Route     string `json:"route"`
+				Status    int    `json:"status"`
+				Bytes     int    `json:"bytes"`
+				APIKey    string `json:"apiKey"`
+			}
+
+			if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+				t.Fatalf("failed to decode access log %q: %v", logs.String(), err)
+			}
+
+			if line.Msg != "request" || line.RequestID != requestID || line.Method != http.MethodGet ||
+				line.Route != "GET /order/{orderId}" || line.Status != http.StatusOK || line.Bytes != rr.Body.Len() ||
+				line.APIKey != "test" {
+				t.Errorf("unexpected access log %+v", line)
+			}
+		})
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	server := newTestServer(&mockProductService{}, &mockOrderService{})
+	server.metrics = repositories.NewMetrics(nil)
+
+	handler := server.RegisterRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("MADEUP", "/health", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != prometheusContentType {
+		t.Fatalf("expected 200 with %q, got %d with %q", prometheusContentType, rr.Code, rr.Header().Get("Content-Type"))
+	}
+
+	for _, want := range []string{
+		`orderfoodonline_http_requests_total{method="GET",route="GET /health",status="200"} 1`,
+		`orderfoodonline_http_requests_total{method="other",route="unmatched",status="405"} 1`,
+		"orderfoodonline_http_requests_in_flight 1",
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("expected the metrics to contain %q, got:\n%s", want, rr.Body.String())
+		}
+	}
+}
+
+func TestMetricsMiddleware_Panic(t *testing.T) {
+	server := newTestServer(&mockProductService{}, &mockOrderService{})
+	server.metrics = repositories.NewMetrics(nil)
+
+	panicking := server.metricsMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("handler failed")
+	}))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to carry on past the middleware")
+			}
+		}()
+
+		panicking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boom", nil))
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	server.RegisterRoutes().ServeHTTP(rr, req)
+
+	for _, want := range []string{
+		`orderfoodonline_http_requests_total{method="GET",route="unmatched",status="500"} 1`,
+		"orderfoodonline_http_requests_in_flight 1",
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("expected the metrics to contain %q, got:\n%s", want, rr.Body.String())
+		}
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	var spans bytes.Buffer
+
+	server := newTestServer(&mockProductService{}, &mockOrderService{})
+	server.tracer = tracing.NewTracer(tracing.WithExporter(tracing.NewJSONExporter(&spans)))
+
+	handler := server.RegisterRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(traceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var span entities.SpanRecord
+	if err := json.Unmarshal(spans.Bytes(), &span); err != nil {
+		t.Fatalf("failed to decode span %q: %v", spans.String(), err)
+	}
+
+	if span.Name != "GET /health" || span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		span.ParentSpanID != "00f067aa0ba902b7" || span.Attributes["http.status_code"] != float64(http.StatusOK) {
+		t.Errorf("unexpected span %+v", span)
+	}
+
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.SpanID + "-01"
+	if traceResponse := rr.Header().Get(traceResponseHeader); traceResponse != expected {
+		t.Errorf("expected traceresponse %s, got %s", expected, traceResponse)
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+const (
+	apiKeyHeader   = "api_key"
+	adminKeyHeader = "admin_key"
+)
+
+type apiKeyContextKey struct{}
+
+// routePolicy: who may call a route. Public routes need no API key, the others a key with scope.
+type routePolicy struct {
+	public bool
+	scope  entities.Scope
+}
+
+var publicRoute = routePolicy{public: true}
+
+func requireScope(scope entities.Scope) routePolicy {
+	return routePolicy{scope: scope}
+}
+
+// handle registers h for pattern, behind the auth policy and rate limit, with the deadline of the route and traced.
+// The rate limit comes after auth so authenticated clients are limited per API key; auth limits its own rejections.
+func (a *apiServer) handle(mux *http.ServeMux, pattern string, policy routePolicy, h http.Handler) {
+	h = a.rateLimitMiddleware(pattern, h)
+
+	if !policy.public {
+		h = a.authMiddleware(pattern, policy.scope, h)
+	}
+
+	mux.Handle(pattern, a.traceMiddleware(pattern, a.withTimeout(pattern, h)))
+}
+
+func (a *apiServer) handleFunc(mux *http.ServeMux, pattern string, policy routePolicy, h http.HandlerFunc) {
+	a.handle(mux, pattern, policy, h)
+}
+
+// catalogPolicy: the catalog is public unless WithPublicCatalog(false) makes it require products:read.
+func (a *apiServer) catalogPolicy() routePolicy {
+	if a.publicCatalog {
+		return publicRoute
+	}
+
+	return requireScope(entities.ScopeProductsRead)
+}
+
+// authMiddleware lets requests through whose API key is valid and has scope. The key is read from the api_key header,
+// or the admin_key header admin clients used before keys had scopes.
+// Requests without a valid key spend a token of the rate limit of pattern for their client IP, and once those are
+// spent requests from the IP get 429 before their key is checked, so keys cannot be guessed at full speed.
+func (a *apiServer) authMiddleware(pattern string, scope entities.Scope, h http.Handler) http.Handler {
+	limit, limited := a.rateLimitFor(pattern)
+
+	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientIP(r)
+		failures := pattern + " auth " + client
+
+		if limited {
+			decision, err := a.rateLimiter.Peek(r.Context(), failures, limit)
+			if err == nil && !decision.Allowed {
+				a.writeRateLimited(w, r, client, pattern, decision)
+
+				return
+			}
+		}
+
+		rejected := func(err error) {
+			if limited {
+				_, _ = a.rateLimiter.Take(r.Context(), failures, limit)
+			}
+
+			a.writeError(w, r, err)
+		}
+
+		key := r.Header.Get(apiKeyHeader)
+		if key == "" {
+			key = r.Header.Get(adminKeyHeader)
+		}
+
+		if key == "" {
+			a.loggerFor(r).Error(constants.MissingAPIkey)
+			rejected(constants.ErrMissingAPIKey)
+
+			return
+		}
+
+		if a.keyStore == nil {
+			a.loggerFor(r).Error(constants.InvalidAPIkey, slog.String("reason", "no API key store configured"))
+			a.writeError(w, r, constants.ErrInvalidAPIKey)
+
+			return
+		}
+
+		apiKey, err := a.keyStore.Authenticate(r.Context(), key)
+		if err != nil {
+			if a.requestAborted(w, r, err) {
+				return
+			}
+
+			a.loggerFor(r).Error(err.Error())
+			rejected(err)
+
+			return
+		}
+
+		if !apiKey.HasScope(scope) {
+			a.loggerFor(r).Warn(constants.MissingScope, slog.String("key", apiKey.Name), slog.String("scope", string(scope)))
+			a.writeError(w, r, constants.ErrMissingScope)
+
+			return
+		}
+
+		recordAPIKey(r.Context(), apiKey.Name)
+
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, apiKey)))
+	})
+
+	return hf
+}
+
+// apiKeyFromContext: the API key the request was authenticated with, nil for requests that were not.
+func apiKeyFromContext(ctx context.Context) *entities.APIKey {
+	apiKey, _ := ctx.Value(apiKeyContextKey{}).(*entities.APIKey)
+
+	return apiKey
+}
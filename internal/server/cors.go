@@ -0,0 +1,119 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// corsMiddleware applies the CORS policy. Preflight requests are answered here with 204, before authentication,
+// as browsers send them without credentials. Requests from origins outside the policy get no CORS headers,
+// and a disallowed preflight gets 403. With credentials allowed, only exact origins are within the policy.
+func (a *apiServer) corsMiddleware(h http.Handler) http.Handler {
+	allowMethods := strings.Join(a.corsPolicy.AllowedMethods, ", ")
+	allowHeaders := strings.Join(a.corsPolicy.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(a.corsPolicy.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(a.corsPolicy.MaxAge.Seconds()))
+
+	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if origin == "" {
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		if !a.originAllowed(origin) || (a.corsPolicy.AllowCredentials && !a.originListed(origin)) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
+		if a.corsPolicy.AllowCredentials || !a.anyOriginAllowed() {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if a.corsPolicy.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+
+			if a.corsPolicy.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		if exposeHeaders != "" {
+			w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+
+	return hf
+}
+
+func (a *apiServer) anyOriginAllowed() bool {
+	for _, allowed := range a.corsPolicy.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// originListed: whether origin is one of the exact allowed origins. With credentials allowed only these are
+// echoed back, as a browser sends its credentials to whatever origin it gets back.
+func (a *apiServer) originListed(origin string) bool {
+	for _, allowed := range a.corsPolicy.AllowedOrigins {
+		if strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// originAllowed matches origin against the allowed origins. "https://*.example.com" matches the subdomains of
+// example.com at any depth over https, but not example.com itself.
+func (a *apiServer) originAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+
+	for _, allowed := range a.corsPolicy.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		scheme, domain, found := strings.Cut(allowed, "://*.")
+		if found && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+domain) &&
+			len(origin) > len(scheme+"://."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// hopByHopHeaders describe a single connection and are not replayed.
+var hopByHopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Proxy-Connection",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// responseRecorder passes the response through and keeps a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	r.body.Write(b)
+
+	return r.ResponseWriter.Write(b)
+}
+
+// idempotencyMiddleware honours the Idempotency-Key header. A retry with the same key and body replays the recorded
+// response, the same key with a different body gets 409. Server errors and cancelled requests are not recorded so
+// they can be retried, nor are bodies over maxIdempotentRequestBytes, which get 413.
+func (a *apiServer) idempotencyMiddleware(h http.Handler) http.Handler {
+	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+
+		if key == "" || a.idempotencyStore == nil {
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			a.writeError(w, r, constants.ErrInvalidIdempotencyKey)
+
+			return
+		}
+
+		// one byte over the limit tells a body that is too large from one that fits exactly.
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentRequestBytes+1))
+		if err != nil {
+			a.loggerFor(r).Error(err.Error())
+			a.writeError(w, r, constants.ErrMalformedRequest)
+
+			return
+		}
+
+		if len(body) > maxIdempotentRequestBytes {
+			a.loggerFor(r).Warn(constants.ErrRequestTooLarge.Error(), slog.String("idempotencyKey", key))
+			a.writeError(w, r, fmt.Errorf("%w: at most %d bytes with an %s", constants.ErrRequestTooLarge,
+				maxIdempotentRequestBytes, idempotencyKeyHeader))
+
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := scopedIdempotencyKey(r, key)
+
+		recorded, err := a.idempotencyStore.Begin(r.Context(), storeKey, requestFingerprint(r, body))
+		if err != nil {
+			a.loggerFor(r).Warn(err.Error(), slog.String("idempotencyKey", key))
+			a.writeError(w, r, err)
+
+			return
+		}
+
+		if recorded != nil {
+			a.loggerFor(r).Info("replaying idempotent response", slog.String("idempotencyKey", key))
+
+			for name, values := range recorded.Header {
+				w.Header()[name] = slices.Clone(values)
+			}
+
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(recorded.Status)
+			_, _ = w.Write(recorded.Body)
+
+			return
+		}
+
+		// the headers already set belong to this request alone (request id, rate limits, CORS), so only those
+		// the handler sets are recorded.
+		before := w.Header().Clone()
+		recorder := &responseRecorder{ResponseWriter: w}
+
+		h.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError || r.Context().Err() != nil {
+			_ = a.idempotencyStore.Release(context.WithoutCancel(r.Context()), storeKey)
+
+			return
+		}
+
+		err = a.idempotencyStore.Complete(context.WithoutCancel(r.Context()), storeKey, entities.IdempotentResponse{
+			Status: recorder.status,
+			Header: handlerHeaders(before, recorder.Header()),
+			Body:   recorder.body.Bytes(),
+		})
+		if err != nil {
+			a.loggerFor(r).Error(err.Error())
+		}
+	})
+
+	return hf
+}
+
+// handlerHeaders: the headers of after that are new or changed since before, less the hop-by-hop headers
+// and those Connection names.
+func handlerHeaders(before, after http.Header) http.Header {
+	header := http.Header{}
+
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			header[name] = slices.Clone(values)
+		}
+	}
+
+	for _, connection := range after.Values("Connection") {
+		for _, name := range strings.Split(connection, ",") {
+			header.Del(strings.TrimSpace(name))
+		}
+	}
+
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
+	}
+
+	return header
+}
+
+// scopedIdempotencyKey keeps the idempotency keys of different API keys apart, so one client
+// can neither replay nor block the requests of another.
+func scopedIdempotencyKey(r *http.Request, key string) string {
+	if apiKey := apiKeyFromContext(r.Context()); apiKey != nil {
+		return apiKey.Name + "\x00" + key
+	}
+
+	return key
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
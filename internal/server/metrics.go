@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+	otherMethod           = "other"
+)
+
+// metricsMiddleware counts and times the requests by method, route pattern and status, and tracks those in flight.
+func (a *apiServer) metricsMiddleware(h http.Handler) http.Handler {
+	if a.metrics == nil {
+		return h
+	}
+
+	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		a.metrics.RequestStarted()
+
+		rec := &statusRecorder{ResponseWriter: w}
+
+		// finish in a defer so a panicking handler still leaves the in-flight gauge, counting it as a 500
+		// unless it wrote a status first; the panic carries on to the server.
+		defer func() {
+			p := recover()
+			if p != nil && rec.status == 0 {
+				rec.status = http.StatusInternalServerError
+			}
+
+			route := r.Pattern
+			if route == "" {
+				route = unmatchedRoutePattern
+			}
+
+			a.metrics.RequestFinished(metricMethod(r.Method), route, rec.statusCode(), time.Since(start))
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		h.ServeHTTP(rec, r)
+	})
+
+	return hf
+}
+
+// metricMethod: the method label of a request. Non standard methods share "other", so clients cannot create
+// label values at will.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	default:
+		return otherMethod
+	}
+}
+
+// Metrics writes the metrics in the Prometheus text exposition format.
+func (a *apiServer) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+
+	if err := a.metrics.WritePrometheus(r.Context(), w); err != nil {
+		a.loggerFor(r).Error(err.Error())
+	}
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:orderfoodonline:problem:"
+)
+
+// problemType: the stable code, status and title of the responses to an error.
+type problemType struct {
+	err    error
+	code   string
+	status int
+	title  string
+}
+
+// problemCatalog maps the errors clients can act on to their problem types. Errors are matched with errors.Is,
+// in order. Other errors are internal: their details are logged but not sent to the client.
+var problemCatalog = []problemType{
+	{context.DeadlineExceeded, "request_timeout", http.StatusGatewayTimeout, "Request timed out"},
+	{context.Canceled, "request_cancelled", StatusClientClosedRequest, "Request cancelled by the client"},
+
+	{constants.ErrMalformedRequest, "malformed_request", http.StatusBadRequest, "Malformed request"},
+	{constants.ErrRequestTooLarge, "request_too_large", http.StatusRequestEntityTooLarge, "Request too large"},
+	{constants.ErrInvalidProductID, "invalid_product_id", http.StatusBadRequest, "Invalid product id"},
+	{constants.ErrInvalidPagination, "invalid_pagination", http.StatusBadRequest, "Invalid offset or limit"},
+	{constants.ErrInvalidProductSort, "invalid_sort", http.StatusBadRequest, "Invalid sort"},
+	{constants.ErrInvalidPriceFilter, "invalid_price_filter", http.StatusBadRequest, "Invalid price filter"},
+
+	{constants.ErrMissingAPIKey, "missing_api_key", http.StatusUnauthorized, "Missing API key"},
+	{constants.ErrInvalidAPIKey, "invalid_api_key", http.StatusUnauthorized, "Invalid API key"},
+	{constants.ErrAPIKeyExpired, "api_key_expired", http.StatusUnauthorized, "API key expired"},
+	{constants.ErrMissingScope, "insufficient_scope", http.StatusForbidden, "Insufficient scope"},
+	{constants.ErrTooManyRequests, "rate_limited", http.StatusTooManyRequests, "Too many requests"},
+
+	{constants.ErrInvalidIdempotencyKey, "invalid_idempotency_key", http.StatusBadRequest, "Invalid idempotency key"},
+	{constants.ErrIdempotencyKeyReused, "idempotency_key_reused", http.StatusConflict, "Idempotency key reused"},
+	{constants.ErrIdempotencyKeyInUse, "idempotency_key_in_use", http.StatusConflict, "Idempotency key in use"},
+
+	{constants.ErrValidationFailed, "validation_failed", http.StatusUnprocessableEntity, "Validation failed"},
+	{constants.ErrNoItemsInOrderReqd, "items_required", http.StatusUnprocessableEntity, "Order has no items"},
+	{constants.ErrTooManyOrderItems, "too_many_items", http.StatusUnprocessableEntity, "Too many items"},
+	{constants.ErrProductItemReqd, "product_id_required", http.StatusUnprocessableEntity, "Product id required"},
+	{constants.ErrNonNumericProductID, "product_id_not_numeric", http.StatusUnprocessableEntity, "Product id not numeric"},
+	{constants.ErrProductIDLeadingZero, "product_id_leading_zero", http.StatusUnprocessableEntity, "Product id with leading zeros"},
+	{constants.ErrProductQtyReqd, "quantity_required", http.StatusUnprocessableEntity, "Quantity required"},
+	{constants.ErrProductQtyTooLarge, "quantity_too_large", http.StatusUnprocessableEntity, "Quantity too large"},
+	{constants.ErrDuplicateOrderItem, "duplicate_item", http.StatusUnprocessableEntity, "Duplicate item"},
+	{constants.ErrModifierReqd, "modifier_required", http.StatusUnprocessableEntity, "Incomplete modifier"},
+	{constants.ErrInvalidModifierSelection, "invalid_modifier_selection", http.StatusUnprocessableEntity, "Invalid modifier selection"},
+	{constants.ErrInvalidPromoCodeLength, "invalid_coupon_length", http.StatusUnprocessableEntity, "Invalid coupon code length"},
+	{constants.ErrInvalidPromoCode, "invalid_coupon", http.StatusBadRequest, "Invalid coupon code"},
+	{constants.ErrUnknownOrderProduct, "unknown_product", http.StatusBadRequest, "Unknown product"},
+	{constants.ErrInsufficientStock, "insufficient_stock", http.StatusConflict, "Insufficient stock"},
+
+	{constants.ErrProductNotFound, "product_not_found", http.StatusNotFound, "Product not found"},
+	{constants.ErrCategoryNotFound, "category_not_found", http.StatusNotFound, "Category not found"},
+	{constants.ErrOrderNotFound, "order_not_found", http.StatusNotFound, "Order not found"},
+	{constants.ErrInvalidOrderStatus, "invalid_order_status", http.StatusUnprocessableEntity, "Invalid order status"},
+	{constants.ErrIllegalStatusTransition, "illegal_status_transition", http.StatusConflict, "Illegal status transition"},
+
+	{constants.ErrInvalidProduct, "invalid_product", http.StatusUnprocessableEntity, "Invalid product"},
+	{constants.ErrProductExists, "product_exists", http.StatusConflict, "Product already exists"},
+}
+
+var internalProblem = problemType{code: "internal_error", status: http.StatusInternalServerError, title: "Internal server error"}
+
+func lookupProblem(err error) (problemType, bool) {
+	for _, problem := range problemCatalog {
+		if errors.Is(err, problem.err) {
+			return problem, true
+		}
+	}
+
+	return internalProblem, false
+}
+
+// newProblem builds the problem details of err for the request r.
+func newProblem(r *http.Request, err error) entities.Problem {
+	problem, known := lookupProblem(err)
+
+	res := entities.Problem{
+		Type:     problemTypePrefix + problem.code,
+		Title:    problem.title,
+		Status:   problem.status,
+		Instance: r.URL.Path,
+		Code:     problem.code,
+	}
+
+	if !known {
+		return res
+	}
+
+	res.Detail = err.Error()
+
+	var (
+		validationErrs entities.ValidationErrors
+		fieldErr       entities.FieldError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fieldErr := range validationErrs {
+			res.Errors = append(res.Errors, newProblemField(fieldErr))
+		}
+	case errors.As(err, &fieldErr):
+		res.Errors = []entities.ProblemField{newProblemField(fieldErr)}
+	}
+
+	return res
+}
+
+func newProblemField(fieldErr entities.FieldError) entities.ProblemField {
+	problem, _ := lookupProblem(fieldErr.Err)
+
+	return entities.ProblemField{Field: fieldErr.Field, Code: problem.code, Detail: fieldErr.Err.Error()}
+}
+
+// writeError writes the response to a failed request: problem+json, or the legacy envelope for clients that
+// prefer application/json.
+func (a *apiServer) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := newProblem(r, err)
+
+	if prefersLegacyEnvelope(r) {
+		message := problem.Detail
+		if message == "" {
+			message = problem.Title
+		}
+
+		var data any
+		if len(problem.Errors) > 0 {
+			data = problem.Errors
+		}
+
+		a.writeJSONResponse(w, problem.Status, constants.FAILURE, message, data)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		a.loggerFor(r).Error(err.Error())
+	}
+}
+
+// prefersLegacyEnvelope: whether the Accept header ranks application/json above application/problem+json.
+// Clients that accept anything get problem+json.
+func prefersLegacyEnvelope(r *http.Request) bool {
+	jsonQuality, problemQuality := 0.0, 0.0
+
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := params["q"]; found {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case "application/json":
+			jsonQuality = max(jsonQuality, quality)
+		case problemContentType:
+			problemQuality = max(problemQuality, quality)
+		}
+	}
+
+	return jsonQuality > problemQuality
+}
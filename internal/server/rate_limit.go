@@ -0,0 +1,102 @@
+package server
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+	"github.com/sunimalherath/orderfoodonline/internal/core/entities"
+)
+
+// rateLimitMiddleware limits the requests per client to the rate limit of pattern, or the constants.AnyRoute limit.
+// Clients are told apart by their API key and otherwise by IP address. Rejected requests get 429 with Retry-After,
+// every response gets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+func (a *apiServer) rateLimitMiddleware(pattern string, h http.Handler) http.Handler {
+	limit, found := a.rateLimitFor(pattern)
+	if !found {
+		return h
+	}
+
+	policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(int(limit.Per.Seconds()))
+
+	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := rateLimitClient(r)
+
+		decision, err := a.rateLimiter.Take(r.Context(), pattern+" "+client, limit)
+		if err != nil {
+			if a.requestAborted(w, r, err) {
+				return
+			}
+
+			a.loggerFor(r).Error(err.Error())
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", policy)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(decision.Reset))
+
+		if !decision.Allowed {
+			a.writeRateLimited(w, r, client, pattern, decision)
+
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+
+	return hf
+}
+
+// rateLimitFor: the rate limit of pattern, or the constants.AnyRoute limit. found is false when requests to pattern
+// are not limited.
+func (a *apiServer) rateLimitFor(pattern string) (entities.RateLimit, bool) {
+	if a.rateLimiter == nil {
+		return entities.RateLimit{}, false
+	}
+
+	limit, found := a.rateLimits[pattern]
+	if !found {
+		limit, found = a.rateLimits[constants.AnyRoute]
+	}
+
+	return limit, found
+}
+
+func (a *apiServer) writeRateLimited(w http.ResponseWriter, r *http.Request, client, pattern string,
+	decision entities.RateLimitDecision,
+) {
+	a.loggerFor(r).Warn(constants.TooManyRequests, slog.String("client", client), slog.String("route", pattern))
+
+	w.Header().Set("Retry-After", ceilSeconds(decision.RetryAfter))
+	a.writeError(w, r, constants.ErrTooManyRequests)
+}
+
+// rateLimitClient: the name of the API key of the request, or the IP address of the client.
+func rateLimitClient(r *http.Request) string {
+	if apiKey := apiKeyFromContext(r.Context()); apiKey != nil {
+		return "key:" + apiKey.Name
+	}
+
+	return clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
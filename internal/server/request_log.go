@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sunimalherath/orderfoodonline/internal/app/utils"
+)
+
+const (
+	requestIDHeader       = "X-Request-ID"
+	maxRequestIDLength    = 128
+	unmatchedRoutePattern = "unmatched"
+)
+
+type accessLogContextKey struct{}
+
+// accessLogEntry: what the access log line reports about a request. Middlewares deeper in the chain, which see
+// a derived request, fill it in through the request context.
+type accessLogEntry struct {
+	apiKeyName string
+}
+
+// statusRecorder captures the status and size of a response for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// statusCode: the status of the response, 200 when the handler wrote nothing.
+func (r *statusRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+
+	return r.status
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+
+	return n, err
+}
+
+// requestLogMiddleware gives every request an id, taken from the X-Request-ID header when the client sent a valid one,
+// and echoes it in the response. The request context carries a logger tagged with the id, which handlers and services
+// log with. Once the request is served, one access log line reports it.
+func (a *apiServer) requestLogMiddleware(h http.Handler) http.Handler {
+	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+
+		logger := a.logger.With(slog.String("requestId", requestID))
+		entry := &accessLogEntry{}
+
+		ctx := utils.ContextWithLogger(r.Context(), logger)
+		ctx = context.WithValue(ctx, accessLogContextKey{}, entry)
+		r = r.WithContext(ctx)
+
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = unmatchedRoutePattern
+		}
+
+		level := slog.LevelInfo
+		if rec.statusCode() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		logger.LogAttrs(context.WithoutCancel(ctx), level, "request",
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.statusCode()),
+			slog.Int("bytes", rec.bytes),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("apiKey", entry.apiKeyName),
+		)
+	})
+
+	return hf
+}
+
+// loggerFor: the logger of the request, tagged with its id.
+func (a *apiServer) loggerFor(r *http.Request) *slog.Logger {
+	return utils.LoggerFromContext(r.Context(), a.logger)
+}
+
+// recordAPIKey reports the name of the API key the request authenticated with in its access log line.
+func recordAPIKey(ctx context.Context, name string) {
+	if entry, ok := ctx.Value(accessLogContextKey{}).(*accessLogEntry); ok {
+		entry.apiKeyName = name
+	}
+}
+
+// validRequestID: client supplied ids are logged and echoed, so only short ids of safe characters are accepted.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlnum && c != '-' && c != '_' && c != '.' && c != ':' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/sunimalherath/orderfoodonline/internal/core/constants"
+)
+
+// StatusClientClosedRequest: the client went away before the response was written (the nginx convention).
+const StatusClientClosedRequest = 499
+
+// withTimeout derives the request context of h from the client's, with the deadline configured for pattern.
+// Client disconnects and server shutdown cancel it as well.
+func (a *apiServer) withTimeout(pattern string, h http.Handler) http.Handler {
+	timeout := a.requestTimeout
+	if routeTimeout, found := a.routeTimeouts[pattern]; found {
+		timeout = routeTimeout
+	}
+
+	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if timeout <= 0 {
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	return hf
+}
+
+// requestAborted writes the response of a request whose context ended: 504 when its deadline passed and 499 when
+// the client cancelled it. Client cancels are not server errors, so they are only logged as warnings.
+// It returns false for other errors, which the handler reports itself.
+func (a *apiServer) requestAborted(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		a.loggerFor(r).Error(constants.RequestTimedOut, slog.String("method", r.Method), slog.String("path", r.URL.Path))
+		a.writeError(w, r, err)
+
+		return true
+	case errors.Is(err, context.Canceled):
+		a.loggerFor(r).Warn(constants.RequestCancelled, slog.String("method", r.Method), slog.String("path", r.URL.Path))
+		a.writeError(w, r, err)
+
+		return true
+	default:
+		return false
+	}
+}
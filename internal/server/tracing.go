@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/sunimalherath/orderfoodonline/internal/app/utils"
+)
+
+const (
+	traceParentHeader   = "traceparent"
+	traceResponseHeader = "traceresponse"
+)
+
+// traceMiddleware serves every request of pattern in a span, the child of the traceparent header when the client
+// sent a valid one. The request logger is tagged with the trace id, and the traceresponse header carries the trace
+// context of the span, in the traceparent format, so clients can look the trace up.
+func (a *apiServer) traceMiddleware(pattern string, h http.Handler) http.Handler {
+	if a.tracer == nil {
+		return h
+	}
+
+	hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := a.tracer.Extract(r.Context(), r.Header.Get(traceParentHeader))
+
+		ctx, span := a.tracer.Start(ctx, pattern)
+		defer span.End()
+
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.route", pattern)
+		span.SetAttribute("http.target", r.URL.Path)
+
+		ctx = utils.ContextWithLogger(ctx, a.loggerFor(r).With(slog.String("traceId", span.TraceID())))
+
+		w.Header().Set(traceResponseHeader, span.TraceParent())
+
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.statusCode()
+		span.SetAttribute("http.status_code", status)
+
+		if status >= http.StatusInternalServerError {
+			span.RecordError(fmt.Errorf("%d %s", status, http.StatusText(status)))
+		}
+	})
+
+	return hf
+}